@@ -1,14 +1,22 @@
 package etcdaero
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	// 10,05 mins
 	aerospikeTTL = 10050 * time.Millisecond
+
+	// the pause before the closed watch of notifier is opened again
+	notifyRetry = time.Second
 )
 
 // IEntryData data buffer for export and import
@@ -19,54 +27,209 @@ type IAeroBody interface {
 
 type AeroChecker struct {
 	sync.RWMutex
-	Conn     *AeroSpikeClient
+	Conn     Store
 	List     map[string]IAeroBody
+	addr     map[string]*StoreKey
+	versions map[string]string
+	states   map[string]*readerState
 	SignalCh chan bool
 	StopCh   chan bool
 	SetTTLCh chan time.Duration
+
+	decompressed compressCounter
+	skipped      int64
+
+	onError atomic.Value
+	metrics atomic.Value
+	tracer  atomic.Value
+	clock   atomic.Value
+	log     atomic.Pointer[logger]
+
+	notifier    Notifier
+	notifiers   []Notifier
+	watched     map[string]bool
+	watchCtx    context.Context
+	watchCancel context.CancelFunc
 }
 
-var singletonAero *AeroChecker
+// defaultAero is used by package-level functions,
+// defaultStore is its store config if it is made by New
+var (
+	defaultAeroMu sync.Mutex
+	defaultAero   *AeroChecker
+	defaultStore  *storeConfig
+)
 
 // FOR TEST ONLY?
-func _cleanSingletonAero() {
-	if singletonAero == nil {
-		return
+func _cleanDefaultAero() {
+	defaultAeroMu.Lock()
+	aero := defaultAero
+	defaultAero, defaultStore = nil, nil
+	defaultAeroMu.Unlock()
+
+	if aero != nil {
+		aero.Close()
 	}
-
-	singletonAero.StopCh <- true
-	singletonAero.Conn.Close()
-	singletonAero = nil
 }
 
-func InitAeroChecker(conn *AeroSpikeClient) *AeroChecker {
+// InitAeroChecker makes the default AeroChecker for package-level functions.
+// If it is made already it is returned and conn is not used,
+// use NewAeroChecker for own instances.
+func InitAeroChecker(conn Store) *AeroChecker {
+	defaultAeroMu.Lock()
+	defer defaultAeroMu.Unlock()
 
-	if singletonAero != nil {
-		return singletonAero
+	if defaultAero == nil {
+		defaultAero = NewAeroChecker(conn)
 	}
 
-	singletonAero = _initAeroChecker(conn)
-
-	return singletonAero
+	return defaultAero
 }
 
-func _initAeroChecker(conn *AeroSpikeClient) *AeroChecker {
+// DefaultAeroChecker returns the default AeroChecker or nil.
+func DefaultAeroChecker() *AeroChecker {
+	defaultAeroMu.Lock()
+	defer defaultAeroMu.Unlock()
 
-	if singletonAero != nil {
-		return singletonAero
-	}
+	return defaultAero
+}
 
-	singletonAero = &AeroChecker{
+// NewAeroChecker makes AeroChecker which reads from conn and starts it.
+func NewAeroChecker(conn Store) *AeroChecker {
+	aero := &AeroChecker{
 		Conn:     conn,
 		List:     map[string]IAeroBody{},
+		addr:     map[string]*StoreKey{},
+		versions: map[string]string{},
+		states:   map[string]*readerState{},
 		SignalCh: make(chan bool, 100),
 		StopCh:   make(chan bool, 2),
 		SetTTLCh: make(chan time.Duration, 2),
+		watched:  map[string]bool{},
+	}
+
+	aero.log.Store(newLogger(nil, nil, 0))
+	aero.SetMetrics(nil)
+	aero.SetTracerProvider(nil)
+	aero.SetClock(nil)
+
+	go aero._start()
+
+	return aero
+}
+
+// Close stops reading and watching and closes conn.
+func (aero *AeroChecker) Close() {
+	aero.Lock()
+	if aero.watchCancel != nil {
+		aero.watchCancel()
+	}
+	aero.Unlock()
+
+	aero.StopCh <- true
+	aero.Conn.Close()
+}
+
+// Watch reloads readers at once when new versions of their entries are published to n,
+// the poll is kept as fallback. Only the first notifier is used, the next ones replace it
+// when it is removed by Unwatch. Each Watch is undone by one Unwatch.
+func (aero *AeroChecker) Watch(n Notifier) {
+	if n == nil {
+		return
+	}
+
+	aero.Lock()
+	defer aero.Unlock()
+
+	aero.notifiers = append(aero.notifiers, n)
+	if aero.notifier == nil {
+		aero._watchAll()
+	}
+}
+
+// Unwatch removes n which is added by Watch, e.g. before its owner closes it.
+// Watching goes on with the next notifier or stops if there is none.
+// Notifiers are compared by pointer.
+func (aero *AeroChecker) Unwatch(n Notifier) {
+	aero.Lock()
+	defer aero.Unlock()
+
+	for i := range aero.notifiers {
+		if sameInstance(aero.notifiers[i], n) {
+			aero.notifiers = append(aero.notifiers[:i:i], aero.notifiers[i+1:]...)
+			break
+		}
+	}
+
+	if len(aero.notifiers) > 0 && sameInstance(aero.notifiers[0], aero.notifier) {
+		return
+	}
+
+	// the used notifier is removed
+	if aero.watchCancel != nil {
+		aero.watchCancel()
+	}
+	aero.notifier, aero.watchCtx, aero.watchCancel = nil, nil, nil
+	aero.watched = map[string]bool{}
+
+	if len(aero.notifiers) > 0 {
+		aero._watchAll()
+	}
+}
+
+// _watchAll watches entries of all readers with the first notifier, caller holds the mutex
+func (aero *AeroChecker) _watchAll() {
+	aero.notifier = aero.notifiers[0]
+	aero.watchCtx, aero.watchCancel = context.WithCancel(context.Background())
+
+	for _, cacheKey := range aero.addr {
+		aero._watch(cacheKey)
+	}
+}
+
+// _watch starts watching of cacheKey once, caller holds the mutex.
+// The first watch is opened at once, so versions published after return are not missed.
+func (aero *AeroChecker) _watch(cacheKey *StoreKey) {
+	key := notifyKey(cacheKey)
+	if aero.watched[key] {
+		return
 	}
+	aero.watched[key] = true
+
+	ch := aero.notifier.Watch(aero.watchCtx, key)
+	go aero._watchLoop(aero.watchCtx, aero.notifier, key, ch)
+}
+
+// _watchLoop signals reloads on new versions from ch until ctx is done.
+// The notifier may close the channel before it (etcd leader is lost, the revision is compacted),
+// then the watch is opened again and readers reload, versions may be missed meanwhile.
+func (aero *AeroChecker) _watchLoop(ctx context.Context, n Notifier, key string, ch <-chan string) {
+	for {
+		for range ch {
+			aero._signal()
+		}
+		if ctx.Err() != nil {
+			return
+		}
 
-	go singletonAero._start()
+		select {
+		case <-ctx.Done():
+			return
+		case <-aero._after(notifyRetry):
+		}
+		aero._signal()
 
-	return singletonAero
+		ch = n.Watch(ctx, key)
+	}
+}
+
+// _signal queues the load of all readers
+func (aero *AeroChecker) _signal() {
+	select {
+	case aero.SignalCh <- true:
+	default:
+		// the load is queued already
+	}
 }
 
 func (aero *AeroChecker) _start() {
@@ -90,22 +253,138 @@ func (aero *AeroChecker) _load() {
 	list := aero._keys()
 
 	for _, key := range list {
+		aero._loadKey(key)
+	}
 
-		cacheKey := &AeroSpikeKey{
-			Set: key,
-			Pk:  key,
+}
+
+// _loadKey reads entry of key and reloads its reader if the entry is changed,
+// the read is the first child of the reload span
+func (aero *AeroChecker) _loadKey(key string) {
+	start := aero._now()
+	ctx, span := aero._tracer().Start(context.Background(), "etcdaero.reload", trace.WithAttributes(attrKey.String(key)))
+
+	data := &EtcdAeroEntry{}
+	_, read := aero._tracer().Start(ctx, "etcdaero.store.read")
+	version, ok, err := aero._loadEntry(key, aero._addr(key), data)
+	endSpan(read, err)
+
+	if err != nil {
+		aero._readError(key, 0, err)
+	}
+	if !ok {
+		span.SetAttributes(attrSkipped.Bool(err == nil))
+		endSpan(span, err)
+		return
+	}
+
+	endSpan(span, aero._reload(ctx, span, key, version, data, start))
+}
+
+// _reload decodes loaded data into reader of key, the span is linked to the write of entry
+func (aero *AeroChecker) _reload(ctx context.Context, span trace.Span, key, version string, data *EtcdAeroEntry, start time.Time) error {
+	size := len(data.Body)
+
+	span.SetAttributes(attrSize.Int(size), attrVersion.String(version))
+	if link, ok := data.traceLink(); ok {
+		span.AddLink(link)
+	}
+
+	if err := data.decompress(&aero.decompressed); err != nil {
+		err = newError(OpDecode, key, ErrDecodeFailed, fmt.Errorf("%s: %w", data.Compression, err))
+		aero._readError(key, size, err)
+		return err
+	}
+
+	_, renew := startSpan(ctx, "etcdaero.renew", attrKey.String(key), attrSize.Int(len(data.Body)))
+	err := aero.reNew(key, data)
+	endSpan(renew, err)
+	if err != nil {
+		err = newError(OpDecode, key, ErrDecodeFailed, err)
+		aero._readError(key, size, err)
+		return err
+	}
+	aero._setVersion(key, version)
+
+	aero._metrics().Read(key, size, nil)
+	aero._metrics().Reloaded(key, data.Time)
+	aero._state(key, func(state *readerState) {
+		state.loaded = aero._now()
+		state.checked = state.loaded
+		state.dataTime = data.Time
+	})
+
+	aero.log.Load().info("entry is reloaded",
+		"key", key,
+		"version", version,
+		"size", len(data.Body),
+		"duration", aero._now().Sub(start))
+
+	return nil
+}
+
+// _loadEntry loads entry if its version is changed, false without error means it is not changed.
+// Version is the content hash for stores without Versioner.
+func (aero *AeroChecker) _loadEntry(key string, cacheKey *StoreKey, data *EtcdAeroEntry) (string, bool, error) {
+	vs, ok := aero.Conn.(Versioner)
+	if !ok {
+		// only hash and time bins are read if nothing is changed
+		head := binsEntry{binHash: nil, binTime: nil}
+		if ok, err := aero.Conn.LoadEntry(cacheKey, head); !ok {
+			// the entry is not found or is not read, it is not read again
+			return "", false, readError(keyName(cacheKey), err)
 		}
-		data := &EtcdAeroEntry{}
 
-		if ok := aero.Conn.LoadEntry(cacheKey, data); !ok {
+		hash, _ := head[binHash].(string)
+		if hash != "" && hash == aero._version(key) {
+			aero._skip(key, hash, binToTime(head[binTime]))
+			return hash, false, nil
+		}
+
+		if ok, err := aero.Conn.LoadEntry(cacheKey, data); !ok {
 			// Load from cache has mistake
-			log.Printf("Not found in cache. Key: %s.%s", cacheKey.Set, cacheKey.Pk)
-			continue
+			return "", false, readError(keyName(cacheKey), err)
 		}
+		return data.Hash, true, nil
+	}
+
+	version, ok, err := vs.Version(cacheKey)
+	if !ok {
+		return "", false, readError(keyName(cacheKey), err)
+	}
+
+	if version != "" && version == aero._version(key) {
+		// nothing is changed
+		aero._skip(key, version, time.Time{})
+		return version, false, nil
+	}
+
+	if ok, err = vs.LoadVersion(cacheKey, version, data); !ok {
+		return "", false, readError(keyName(cacheKey)+"#"+version, err)
+	}
+
+	return version, true, nil
+}
 
-		aero.reNew(key, data.Body)
+// readError is the error of failed read, nil err means the entry is not found
+func readError(name string, err error) error {
+	if err != nil {
+		return newError(OpRead, name, ErrStoreUnavailable, err)
 	}
+	return newError(OpRead, name, ErrEntryNotFound, nil)
+}
+
+func (aero *AeroChecker) _version(key string) string {
+	aero.RLock()
+	defer aero.RUnlock()
 
+	return aero.versions[key]
+}
+
+func (aero *AeroChecker) _setVersion(key, version string) {
+	aero.Lock()
+	aero.versions[key] = version
+	aero.Unlock()
 }
 
 func (aero *AeroChecker) _keys() []string {
@@ -122,20 +401,142 @@ func (aero *AeroChecker) _keys() []string {
 	return out
 }
 
-func (aero *AeroChecker) reNew(key string, data []byte) {
+// DecompressStats shows decompression of loaded entries.
+func (aero *AeroChecker) DecompressStats() CompressStats {
+	return aero.decompressed.get()
+}
+
+// SetOnError sets the receiver of reading errors, they are logged if it is nil.
+func (aero *AeroChecker) SetOnError(f ErrorFunc) {
+	aero.onError.Store(f)
+}
+
+// SetLogger sets the logger of reading, nil is slog.Default().
+func (aero *AeroChecker) SetLogger(l *slog.Logger) {
+	aero.setLogger(newLogger(l, nil, 0))
+}
+
+// setLogger sets the logger with its clock and rate limit, e.g. of Config
+func (aero *AeroChecker) setLogger(l *logger) {
+	aero.log.Store(l)
+}
+
+// clockBox keeps Clock of any type in atomic.Value
+type clockBox struct {
+	c Clock
+}
+
+// SetClock sets the time of reader states and their age, nil is the system time.
+func (aero *AeroChecker) SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	aero.clock.Store(clockBox{c: c})
+}
+
+func (aero *AeroChecker) _now() time.Time {
+	return aero.clock.Load().(clockBox).c.Now()
+}
+
+func (aero *AeroChecker) _after(d time.Duration) <-chan time.Time {
+	return aero.clock.Load().(clockBox).c.After(d)
+}
+
+// metricsBox keeps Metrics of any type in atomic.Value
+type metricsBox struct {
+	m Metrics
+}
+
+// SetMetrics sets the receiver of reading measurements, nil is no metrics.
+func (aero *AeroChecker) SetMetrics(m Metrics) {
+	aero.metrics.Store(metricsBox{m: metricsOf(m)})
+}
+
+func (aero *AeroChecker) _metrics() Metrics {
+	return aero.metrics.Load().(metricsBox).m
+}
+
+// tracerBox keeps Tracer of any type in atomic.Value
+type tracerBox struct {
+	t trace.Tracer
+}
+
+// SetTracerProvider sets the provider of reload spans, nil is no tracing.
+func (aero *AeroChecker) SetTracerProvider(tp trace.TracerProvider) {
+	aero.tracer.Store(tracerBox{t: tracerOf(tp)})
+}
+
+func (aero *AeroChecker) _tracer() trace.Tracer {
+	return aero.tracer.Load().(tracerBox).t
+}
+
+// _readError reports the failed read of key
+func (aero *AeroChecker) _readError(key string, size int, err error) {
+	aero._error(err)
+	aero._metrics().Read(key, size, err)
+	aero._state(key, func(state *readerState) {
+		state.lastErr = err
+		state.lastErrAt = aero._now()
+	})
+}
+
+func (aero *AeroChecker) _error(err error) {
+	f, _ := aero.onError.Load().(ErrorFunc)
+	reportError(f, aero.log.Load(), err)
+}
+
+func (aero *AeroChecker) _skip(key, version string, loaded time.Time) {
+	atomic.AddInt64(&aero.skipped, 1)
+	aero.log.Load().debug("entry is not changed", "key", key, "version", version)
+	aero._metrics().Reloaded(key, loaded)
+	aero._state(key, func(state *readerState) {
+		state.checked = aero._now()
+		if !loaded.IsZero() {
+			state.dataTime = loaded
+		}
+	})
+}
+
+// SkippedReloads is the count of reloads which are skipped because the entry is not changed.
+func (aero *AeroChecker) SkippedReloads() int64 {
+	return atomic.LoadInt64(&aero.skipped)
+}
+
+// _addr is the cache address of reader key
+func (aero *AeroChecker) _addr(key string) *StoreKey {
+	aero.RLock()
+	defer aero.RUnlock()
+
+	if cacheKey, ok := aero.addr[key]; ok {
+		return cacheKey
+	}
+	return storeKey(key)
+}
+
+func (aero *AeroChecker) reNew(key string, data *EtcdAeroEntry) error {
 	aero.RLock()
 	defer aero.RUnlock()
 
 	obj, ok := aero.List[key]
 	if !ok {
-		return
+		return nil
+	}
+
+	codecObj, ok := obj.(IAeroCodecBody)
+	if !ok {
+		return obj.ReNew(data.Body)
 	}
 
-	obj.ReNew(data)
+	codec, ok := CodecByName(data.Codec)
+	if !ok {
+		return fmt.Errorf("unknown codec %s: %w", data.Codec, ErrIncorrectDataFormat)
+	}
+
+	return codecObj.ReNewCodec(data.Body, codec)
 }
 
 func SetTTLAero(ttl time.Duration) {
-	singletonAero.SetTTL(ttl)
+	DefaultAeroChecker().SetTTL(ttl)
 }
 
 func (aero *AeroChecker) SetTTL(ttl time.Duration) {
@@ -143,37 +544,76 @@ func (aero *AeroChecker) SetTTL(ttl time.Duration) {
 }
 
 func ReLoadAero() {
-	singletonAero.ReLoad()
+	DefaultAeroChecker().ReLoad()
 }
 
+// ReLoad queues the load of all readers, it does not wait if the load is queued already.
 func (aero *AeroChecker) ReLoad() {
-	aero.SignalCh <- true
+	aero._signal()
 }
 
 func StartAeroReader(key string, obj ...IAeroBody) {
+	DefaultAeroChecker().StartReader(key, obj...)
+}
+
+// StartReader registers obj as reader of key, it is map[string]interface{} storage by default.
+func (aero *AeroChecker) StartReader(key string, obj ...IAeroBody) {
+	aero.StartReaderAt(key, storeKey(key), obj...)
+}
+
+// StartReaderAt registers obj as reader of key which is stored at cacheKey.
+func (aero *AeroChecker) StartReaderAt(key string, cacheKey *StoreKey, obj ...IAeroBody) {
 	if len(obj) == 0 {
-		singletonAero._add(key, NewlocalAeroStorage())
+		aero._add(key, cacheKey, NewlocalAeroStorage())
 	} else {
-		singletonAero._add(key, obj[0])
+		aero._add(key, cacheKey, obj[0])
 	}
 }
 
-func (aero *AeroChecker) _add(key string, obj IAeroBody) {
+func (aero *AeroChecker) _add(key string, cacheKey *StoreKey, obj IAeroBody) {
 	aero.Lock()
 	aero.List[key] = obj
+	aero.addr[key] = cacheKey
+	delete(aero.versions, key)
+	delete(aero.states, key)
+	if aero.notifier != nil {
+		aero._watch(cacheKey)
+	}
+	aero.Unlock()
+}
+
+// StopReader removes the reader of key, its data is not reloaded and Get does not find it.
+func (aero *AeroChecker) StopReader(key string) {
+	aero.Lock()
+	delete(aero.List, key)
+	delete(aero.addr, key)
+	delete(aero.versions, key)
+	delete(aero.states, key)
 	aero.Unlock()
 }
 
-func PutAero(key *AeroSpikeKey, data IEntryData, ttl time.Duration) {
-	singletonAero.Put(key, data, ttl)
+func PutAero(key *StoreKey, data IEntryData, ttl time.Duration) {
+	DefaultAeroChecker().Put(key, data, ttl)
 }
 
-func (aero *AeroChecker) Put(key *AeroSpikeKey, data IEntryData, ttl time.Duration) {
+func (aero *AeroChecker) Put(key *StoreKey, data IEntryData, ttl time.Duration) {
 	aero.Conn.PutEntry(key, data, ttl)
 }
 
+// Write puts data at once and returns the error.
+func (aero *AeroChecker) Write(key *StoreKey, data IEntryData, ttl time.Duration) error {
+	return aero.Conn.WriteEntry(key, data, ttl)
+}
+
+// WriteFenced puts data with fencing token, the store rejects it
+// with ErrStaleToken if the stored token is greater.
+// Zero token or Store which is not FencedStore means the plain write.
+func (aero *AeroChecker) WriteFenced(key *StoreKey, data IEntryData, ttl time.Duration, token uint64) error {
+	return writeFenced(aero.Conn, key, data, ttl, token)
+}
+
 func GetAero(key string, params ...interface{}) (interface{}, bool) {
-	return singletonAero.Get(key, params...)
+	return DefaultAeroChecker().Get(key, params...)
 }
 
 func (aero *AeroChecker) Get(key string, params ...interface{}) (interface{}, bool) {
@@ -188,7 +628,7 @@ func (aero *AeroChecker) Get(key string, params ...interface{}) (interface{}, bo
 
 	out, ok, err := obj.Get(params)
 	if err != nil {
-		log.Printf("got for key %s error: %s", key, err)
+		aero._error(newError(OpGet, key, nil, err))
 		return out, false
 	}
 
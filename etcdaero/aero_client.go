@@ -1,12 +1,12 @@
 package etcdaero
 
 import (
-	"log"
 	"net"
 	"strconv"
 	"time"
 
 	aerospike "github.com/aerospike/aerospike-client-go"
+	"github.com/aerospike/aerospike-client-go/types"
 )
 
 const (
@@ -21,16 +21,15 @@ type IEntryData interface {
 	Import(data map[string]interface{}) error
 }
 
-type AeroSpikeKey struct {
-	Set, Pk string
-	Tags    []string
-}
+// AeroSpikeKey is the old name of StoreKey
+type AeroSpikeKey = StoreKey
 
 type AeroSpikeClient struct {
 	prefix    string
 	namespace string
 	client    *aerospike.Client
 	getPolicy *aerospike.BasePolicy
+	errs      putErrors
 }
 
 func NewAeroSpikeClient(cfg *Config) (*AeroSpikeClient, error) {
@@ -67,6 +66,7 @@ func NewAeroSpikeClient(cfg *Config) (*AeroSpikeClient, error) {
 		prefix:    cfg.AeroPrefix,
 		getPolicy: getPolicy,
 		client:    client,
+		errs:      configPutErrors(cfg),
 	}
 
 	return out, nil
@@ -76,7 +76,7 @@ func (as *AeroSpikeClient) createKey(key *AeroSpikeKey) (aeroKey *aerospike.Key,
 	return aerospike.NewKey(as.namespace, key.Set, as.prefix+key.Pk)
 }
 
-// PutEntry store data to cache by goroutines
+// PutEntry store data to cache by goroutines, errors are reported to Config.OnError or logged
 func (a *AeroSpikeClient) PutEntry(key *AeroSpikeKey, data IEntryData, ttl time.Duration) {
 	go a.putEntry(key, data, ttl)
 }
@@ -85,10 +85,34 @@ func (as *AeroSpikeClient) Close() {
 	as.client.Close()
 }
 
+// DeleteEntry removes data from cache
+func (as *AeroSpikeClient) DeleteEntry(key *AeroSpikeKey) error {
+	aKey, err := as.createKey(key)
+	if err != nil {
+		return err
+	}
+
+	policy := aerospike.NewWritePolicy(0, 0)
+	policy.MaxRetries = maxRetries
+
+	_, err = as.client.Delete(policy, aKey)
+	return err
+}
+
 // putEntry store data to cache
 func (as *AeroSpikeClient) putEntry(key *AeroSpikeKey, data IEntryData, ttl time.Duration) {
+	if err := as.WriteEntry(key, data, ttl); err != nil {
+		as.errs.report(key, err)
+	}
+}
 
-	aKey, _ := as.createKey(key)
+// WriteEntry store data to cache at once
+func (as *AeroSpikeClient) WriteEntry(key *AeroSpikeKey, data IEntryData, ttl time.Duration) error {
+
+	aKey, err := as.createKey(key)
+	if err != nil {
+		return err
+	}
 
 	policy := aerospike.NewWritePolicy(0, int32(ttl.Seconds()))
 	policy.MaxRetries = maxRetries
@@ -97,20 +121,66 @@ func (as *AeroSpikeClient) putEntry(key *AeroSpikeKey, data IEntryData, ttl time
 	bins["tags"] = key.Tags
 	bins["id"] = key.Pk
 
-	if err := as.client.Put(policy, aKey, bins); err != nil {
-		log.Printf("PutExternal error: %s", err)
+	return as.client.Put(policy, aKey, bins)
+}
+
+// WriteFenced stores data if the stored token is not greater than token.
+// The token check and the put are atomic by generation of the record.
+func (as *AeroSpikeClient) WriteFenced(key *AeroSpikeKey, data IEntryData, ttl time.Duration, token uint64) error {
+
+	aKey, err := as.createKey(key)
+	if err != nil {
+		return err
 	}
 
-	return
+	bins := data.Export()
+	bins["tags"] = key.Tags
+	bins["id"] = key.Pk
+	bins[binToken] = int64(token)
+
+	for i := 0; i <= maxRetries; i++ {
+		policy := aerospike.NewWritePolicy(0, int32(ttl.Seconds()))
+		policy.MaxRetries = maxRetries
+
+		rec, err := as.client.Get(as.getPolicy, aKey, binToken)
+		switch {
+		case resultCode(err) == types.KEY_NOT_FOUND_ERROR || err == nil && rec == nil:
+			policy.RecordExistsAction = aerospike.CREATE_ONLY
+		case err != nil:
+			return err
+		case toToken(rec.Bins[binToken]) > token:
+			return ErrStaleToken
+		default:
+			policy.GenerationPolicy = aerospike.EXPECT_GEN_EQUAL
+			policy.Generation = rec.Generation
+		}
+
+		err = as.client.Put(policy, aKey, bins)
+		switch resultCode(err) {
+		case types.GENERATION_ERROR, types.KEY_EXISTS_ERROR:
+			// somebody wrote between Get and Put, check the token again
+			continue
+		}
+		return err
+	}
+
+	return errFenceConflict
+}
+
+// resultCode is the code of aerospike error, OK for other errors
+func resultCode(err error) types.ResultCode {
+	if ae, ok := err.(types.AerospikeError); ok {
+		return ae.ResultCode()
+	}
+	return types.OK
 }
 
 // LoadEntry load data with bins
-func (as *AeroSpikeClient) LoadEntry(key *AeroSpikeKey, buf IEntryData) bool {
+func (as *AeroSpikeClient) LoadEntry(key *AeroSpikeKey, buf IEntryData) (bool, error) {
 
 	aKey, err := as.createKey(key)
 	if err != nil {
-		log.Println(err)
-		return false
+		return false, err
 	}
 
 	bins := buf.Export()
@@ -121,18 +191,18 @@ func (as *AeroSpikeClient) LoadEntry(key *AeroSpikeKey, buf IEntryData) bool {
 
 	rec, err := as.client.Get(as.getPolicy, aKey, binSlice...)
 
-	if err != nil {
-		return false
+	if resultCode(err) == types.KEY_NOT_FOUND_ERROR || err == nil && rec == nil {
+		// Cache not found
+		return false, nil
 	}
 
-	if rec == nil {
-		// Cache not found
-		return false
+	if err != nil {
+		return false, err
 	}
 
 	if err = buf.Import(rec.Bins); err != nil {
-		return false
+		return false, err
 	}
 
-	return true
+	return true, nil
 }
@@ -58,7 +58,51 @@ func (s *AeroClientTetsSuite) Test_putEntry_LoadEntry(c *C) {
 	c.Assert(as.client, NotNil)
 
 	buf := EmptyEtcdAeroEntry()
-	find := as.LoadEntry(key, buf)
+	find, err := as.LoadEntry(key, buf)
+	c.Assert(err, IsNil)
 	c.Check(find, Equals, true)
 	c.Check(fmt.Sprintf("%s", buf.Body), Equals, `{"puper":"asdsadsadasd","super":1}`)
 }
+
+func (s *AeroClientTetsSuite) Test_DeleteEntry(c *C) {
+	//c.Skip("Not now")
+
+	entry, err := NewEtcdAeroEntry(map[string]interface{}{"super": 1})
+	c.Assert(err, IsNil)
+
+	as, err := NewAeroSpikeClient(cfgAero)
+	c.Assert(err, IsNil)
+	as.putEntry(key, entry, TTL)
+
+	c.Assert(as.DeleteEntry(key), IsNil)
+
+	buf := EmptyEtcdAeroEntry()
+	find, err := as.LoadEntry(key, buf)
+	c.Assert(err, IsNil)
+	c.Check(find, Equals, false)
+}
+
+func (s *AeroClientTetsSuite) Test_WriteFenced(c *C) {
+	//c.Skip("Not now")
+
+	fenced := &AeroSpikeKey{Set: "sets", Pk: "fenced"}
+
+	as, err := NewAeroSpikeClient(cfgAero)
+	c.Assert(err, IsNil)
+	as.DeleteEntry(fenced)
+
+	old, err := NewEtcdAeroEntry(map[string]interface{}{"leader": "old"})
+	c.Assert(err, IsNil)
+	next, err := NewEtcdAeroEntry(map[string]interface{}{"leader": "new"})
+	c.Assert(err, IsNil)
+
+	c.Assert(as.WriteFenced(fenced, old, TTL, 5), IsNil)
+	c.Assert(as.WriteFenced(fenced, next, TTL, 7), IsNil)
+	c.Check(as.WriteFenced(fenced, old, TTL, 5), Equals, ErrStaleToken)
+
+	buf := EmptyEtcdAeroEntry()
+	find, err := as.LoadEntry(fenced, buf)
+	c.Assert(err, IsNil)
+	c.Check(find, Equals, true)
+	c.Check(fmt.Sprintf("%s", buf.Body), Equals, `{"leader":"new"}`)
+}
@@ -1,34 +1,28 @@
 package etcdaero
 
 import (
-	"encoding/json"
 	"sync"
 )
 
+// localAeroStorage is the default reader, it keeps map[string]interface{}
 type localAeroStorage struct {
 	sync.RWMutex
-	IAeroBody
 	Data interface{}
 }
 
-var Singleton *localAeroStorage
-
-func NewlocalAeroStorage() localAeroStorage {
-	if Singleton == nil {
-		Singleton = &localAeroStorage{
-			Data: nil,
-		}
+func NewlocalAeroStorage() *localAeroStorage {
+	return &localAeroStorage{
+		Data: nil,
 	}
-	return *Singleton
 }
 
-func (puk localAeroStorage) ReNew(data []byte) error {
-	return Singleton._ReNew(data)
+func (puk *localAeroStorage) ReNew(data []byte) error {
+	return puk.ReNewCodec(data, JSONCodec{})
 }
 
-func (puk *localAeroStorage) _ReNew(data []byte) error {
+func (puk *localAeroStorage) ReNewCodec(data []byte, codec Codec) error {
 	d := map[string]interface{}{}
-	if err := json.Unmarshal(data, &d); err != nil {
+	if err := codec.Unmarshal(data, &d); err != nil {
 		return err
 	}
 
@@ -39,11 +33,7 @@ func (puk *localAeroStorage) _ReNew(data []byte) error {
 	return nil
 }
 
-func (puk localAeroStorage) Get(data []interface{}) (interface{}, bool, error) {
-	return Singleton._Get(data)
-}
-
-func (puk *localAeroStorage) _Get(data []interface{}) (interface{}, bool, error) {
+func (puk *localAeroStorage) Get(data []interface{}) (interface{}, bool, error) {
 
 	puk.RLock()
 	res := puk.Data
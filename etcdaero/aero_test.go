@@ -45,7 +45,7 @@ func (aob aeroBodyTest) ReNew(data []byte) error {
 func (s *AeroTetsSuite) Test_InitAeroChecker(c *C) {
 	c.Skip("Not now")
 
-	_cleanSingletonAero()
+	_cleanDefaultAero()
 
 	conn, _ := NewAeroSpikeClient(cfgAero)
 
@@ -56,7 +56,7 @@ func (s *AeroTetsSuite) Test_InitAeroChecker(c *C) {
 func (s *AeroTetsSuite) Test_AddAero_ReLoadAero(c *C) {
 	c.Skip("Not now")
 
-	_cleanSingletonAero()
+	_cleanDefaultAero()
 
 	conn, _ := NewAeroSpikeClient(cfgAero)
 	as := InitAeroChecker(conn)
@@ -65,7 +65,7 @@ func (s *AeroTetsSuite) Test_AddAero_ReLoadAero(c *C) {
 		list: []string{},
 	}
 
-	as._add(keyAero, obj)
+	as._add(keyAero, storeKey(keyAero), obj)
 	// as.ReLoad()
 
 	as.SetTTL(1 * time.Second)
@@ -78,6 +78,6 @@ func (s *AeroTetsSuite) Test_AddAero_ReLoadAero(c *C) {
 	//c.Assert(err, IsNil)
 	c.Assert(as, NotNil)
 
-	//_cleanSingletonAero()
+	//_cleanDefaultAero()
 	c.Assert(nil, NotNil)
 }
@@ -0,0 +1,218 @@
+package etcdaero
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+var ErrChunkBroken = errors.New("chunk is broken")
+
+// chunk bins of manifest record
+const (
+	binChunks       = "chunks"
+	binChunkVersion = "chunkver"
+	binSums         = "sums"
+)
+
+// ChunkedStore splits large bodies into chunk records and manifest record.
+// Manifest is at the entry key and is written after all chunks,
+// chunks of each version have own keys, so readers never see half-written entry.
+// Old versions expire by ttl.
+type ChunkedStore struct {
+	Store
+	ChunkSize int
+
+	errs putErrors
+}
+
+func NewChunkedStore(store Store, chunkSize int) *ChunkedStore {
+	return &ChunkedStore{
+		Store:     store,
+		ChunkSize: chunkSize,
+		errs:      defPutErrors(),
+	}
+}
+
+// chunkKey is the key of chunk i of version
+func chunkKey(key *StoreKey, version string, i int) *StoreKey {
+	return &StoreKey{
+		Set:  key.Set,
+		Pk:   fmt.Sprintf("%s#%s#%d", key.Pk, version, i),
+		Tags: key.Tags,
+	}
+}
+
+// binsEntry is IEntryData for raw bins
+type binsEntry map[string]interface{}
+
+func (b binsEntry) Export() map[string]interface{} {
+	return b
+}
+
+func (b binsEntry) Import(data map[string]interface{}) error {
+	for k, v := range data {
+		b[k] = v
+	}
+	return nil
+}
+
+// PutEntry writes at once, errors are reported.
+func (cs *ChunkedStore) PutEntry(key *StoreKey, data IEntryData, ttl time.Duration) {
+	if err := cs.WriteEntry(key, data, ttl); err != nil {
+		cs.errs.report(key, err)
+	}
+}
+
+// WriteEntry writes chunks and then manifest.
+func (cs *ChunkedStore) WriteEntry(key *StoreKey, data IEntryData, ttl time.Duration) error {
+	return cs.WriteFenced(key, data, ttl, 0)
+}
+
+// WriteFenced writes chunks and then manifest with token.
+// Chunks of the rejected write are not read and expire by ttl.
+func (cs *ChunkedStore) WriteFenced(key *StoreKey, data IEntryData, ttl time.Duration, token uint64) error {
+	bins := data.Export()
+	body, _ := bins["body"].([]byte)
+
+	manifest := binsEntry{}
+	for k, v := range bins {
+		manifest[k] = v
+	}
+
+	if len(body) <= cs.ChunkSize {
+		manifest[binChunks] = 0
+		return writeFenced(cs.Store, key, manifest, ttl, token)
+	}
+
+	version := newVersion()
+	sums := []byte{}
+
+	for i := 0; len(body) > 0; i++ {
+		size := cs.ChunkSize
+		if size > len(body) {
+			size = len(body)
+		}
+
+		sums = binary.BigEndian.AppendUint32(sums, crc32.ChecksumIEEE(body[:size]))
+		if err := cs.Store.WriteEntry(chunkKey(key, version, i), binsEntry{"body": body[:size]}, ttl); err != nil {
+			return err
+		}
+
+		body = body[size:]
+	}
+
+	manifest["body"] = []byte{}
+	manifest[binChunks] = len(sums) / 4
+	manifest[binChunkVersion] = version
+	manifest[binSums] = sums
+
+	return writeFenced(cs.Store, key, manifest, ttl, token)
+}
+
+// LoadEntry reads manifest and joins chunks.
+// Chunks are not read if buf has no body.
+func (cs *ChunkedStore) LoadEntry(key *StoreKey, buf IEntryData) (bool, error) {
+	bins := buf.Export()
+	_, withBody := bins["body"]
+
+	manifest := binsEntry{}
+	for k := range bins {
+		manifest[k] = nil
+	}
+	manifest[binChunks] = nil
+	manifest[binChunkVersion] = nil
+	manifest[binSums] = nil
+
+	if ok, err := cs.Store.LoadEntry(key, manifest); !ok {
+		return false, err
+	}
+
+	if withBody {
+		body, err := cs._join(key, manifest)
+		if err != nil {
+			return false, err
+		}
+		if body != nil {
+			manifest["body"] = body
+		}
+	}
+
+	delete(manifest, binChunks)
+	delete(manifest, binChunkVersion)
+	delete(manifest, binSums)
+
+	if err := buf.Import(manifest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// _join reads chunks of manifest, nil means entry is not chunked
+func (cs *ChunkedStore) _join(key *StoreKey, manifest binsEntry) ([]byte, error) {
+	count := toInt(manifest[binChunks])
+	if count == 0 {
+		return nil, nil
+	}
+
+	version, _ := manifest[binChunkVersion].(string)
+	sums, _ := manifest[binSums].([]byte)
+	if len(sums) != count*4 {
+		return nil, ErrChunkBroken
+	}
+
+	body := []byte{}
+	for i := 0; i < count; i++ {
+		chunk := binsEntry{"body": nil}
+		ok, err := cs.Store.LoadEntry(chunkKey(key, version, i), chunk)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrChunkBroken
+		}
+
+		part, _ := chunk["body"].([]byte)
+		if crc32.ChecksumIEEE(part) != binary.BigEndian.Uint32(sums[i*4:]) {
+			return nil, ErrChunkBroken
+		}
+
+		body = append(body, part...)
+	}
+
+	return body, nil
+}
+
+// DeleteEntry removes manifest and chunks.
+func (cs *ChunkedStore) DeleteEntry(key *StoreKey) error {
+	manifest := binsEntry{binChunks: nil, binChunkVersion: nil}
+	ok, err := cs.Store.LoadEntry(key, manifest)
+	if err != nil {
+		return err
+	}
+	if ok {
+		version, _ := manifest[binChunkVersion].(string)
+		for i := 0; i < toInt(manifest[binChunks]); i++ {
+			cs.Store.DeleteEntry(chunkKey(key, version, i))
+		}
+	}
+
+	return cs.Store.DeleteEntry(key)
+}
+
+// toInt converts bin number, aerospike returns int or int64
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case int32:
+		return int(n)
+	case uint64:
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,21 @@
+package etcdaero
+
+import (
+	"time"
+)
+
+// Clock is the time source of refresh loops and TTLs, tests use a fake one.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
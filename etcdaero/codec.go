@@ -0,0 +1,105 @@
+package etcdaero
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"sync"
+)
+
+// Codec encodes data for the cache. Its name is stored with entry,
+// so readers pick the right decoder.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// IAeroCodecBody is IAeroBody which decodes data by itself.
+// AeroChecker calls ReNewCodec instead of ReNew for it.
+type IAeroCodecBody interface {
+	IAeroBody
+	ReNewCodec(data []byte, codec Codec) error
+}
+
+// Codec names
+const (
+	CodecJSON    = "json"
+	CodecGob     = "gob"
+	CodecMsgpack = "msgpack"
+	CodecProto   = "proto"
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+)
+
+func init() {
+	RegisterCodec(JSONCodec{})
+	RegisterCodec(GobCodec{})
+	RegisterCodec(MsgpackCodec{})
+	RegisterCodec(ProtoCodec{})
+}
+
+// RegisterCodec adds codec for readers, it replaces codec with the same name.
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	codecs[codec.Name()] = codec
+	codecsMu.Unlock()
+}
+
+// CodecByName returns registered codec, "" is JSON for old entries.
+func CodecByName(name string) (Codec, bool) {
+	if name == "" {
+		name = CodecJSON
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// codecOrDefault returns JSON if codec is nil
+func codecOrDefault(codec Codec) Codec {
+	if codec == nil {
+		return JSONCodec{}
+	}
+	return codec
+}
+
+// JSONCodec is encoding/json, it is the default one.
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return CodecJSON
+}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec is encoding/gob, the types inside interface{} must be registered with gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) Name() string {
+	return CodecGob
+}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
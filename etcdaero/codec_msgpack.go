@@ -0,0 +1,20 @@
+package etcdaero
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackCodec is MessagePack, it keeps int and float apart.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Name() string {
+	return CodecMsgpack
+}
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,50 @@
+package etcdaero
+
+import (
+	"errors"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrNotProtoMessage = errors.New("data is not proto.Message")
+
+// ProtoCodec is protobuf, data must be proto.Message.
+// Unmarshal takes proto.Message or pointer to it, the last one is allocated.
+type ProtoCodec struct{}
+
+func (ProtoCodec) Name() string {
+	return CodecProto
+}
+
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(msg)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+
+	// **Message, TypedDataset[*Message] passes it
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Ptr {
+		return ErrNotProtoMessage
+	}
+
+	elem := reflect.New(rv.Elem().Type().Elem())
+	msg, ok := elem.Interface().(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(elem)
+	return nil
+}
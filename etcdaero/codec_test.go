@@ -0,0 +1,98 @@
+package etcdaero
+
+import (
+	. "gopkg.in/check.v1"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodec(t *testing.T) {
+	TestingT(t)
+}
+
+type CodecTestsSuite struct{}
+
+var _ = Suite(&CodecTestsSuite{})
+
+func (s *CodecTestsSuite) Test_CodecByName(c *C) {
+	//c.Skip("Not now")
+
+	for _, name := range []string{CodecJSON, CodecGob, CodecMsgpack, CodecProto} {
+		codec, ok := CodecByName(name)
+		c.Assert(ok, Equals, true)
+		c.Check(codec.Name(), Equals, name)
+	}
+
+	codec, ok := CodecByName("")
+	c.Assert(ok, Equals, true)
+	c.Check(codec.Name(), Equals, CodecJSON)
+
+	_, ok = CodecByName("unknown")
+	c.Check(ok, Equals, false)
+}
+
+func (s *CodecTestsSuite) Test_Map_RoundTrip(c *C) {
+	//c.Skip("Not now")
+
+	data := map[string]interface{}{
+		"int":    int64(1 << 60),
+		"string": "pooh",
+	}
+
+	for _, codec := range []Codec{GobCodec{}, MsgpackCodec{}} {
+		body, err := codec.Marshal(data)
+		c.Assert(err, IsNil)
+
+		storage := NewlocalAeroStorage()
+		c.Assert(storage.ReNewCodec(body, codec), IsNil)
+
+		res, found, err := storage.Get(nil)
+		c.Assert(err, IsNil)
+		c.Check(found, Equals, true)
+		c.Check(res.(map[string]interface{})["int"], Equals, int64(1<<60), Commentf("codec %s", codec.Name()))
+		c.Check(res.(map[string]interface{})["string"], Equals, "pooh")
+	}
+}
+
+func (s *CodecTestsSuite) Test_Proto(c *C) {
+	//c.Skip("Not now")
+
+	codec := ProtoCodec{}
+
+	_, err := codec.Marshal(map[string]interface{}{})
+	c.Check(err, Equals, ErrNotProtoMessage)
+
+	body, err := codec.Marshal(wrapperspb.String("pooh"))
+	c.Assert(err, IsNil)
+
+	msg := &wrapperspb.StringValue{}
+	c.Assert(codec.Unmarshal(body, msg), IsNil)
+	c.Check(msg.GetValue(), Equals, "pooh")
+
+	reader := &typedReader[*wrapperspb.StringValue]{}
+	c.Assert(reader.ReNewCodec(body, codec), IsNil)
+	res, found := reader.get()
+	c.Check(found, Equals, true)
+	c.Check(res.GetValue(), Equals, "pooh")
+}
+
+func (s *CodecTestsSuite) Test_AeroChecker_ReNew_Codec(c *C) {
+	//c.Skip("Not now")
+
+	aero := &AeroChecker{
+		List:     map[string]IAeroBody{},
+		addr:     map[string]*StoreKey{},
+		versions: map[string]string{},
+	}
+	storage := NewlocalAeroStorage()
+	aero._add("key", storeKey("key"), storage)
+
+	entry, err := NewEntryWithCodec(map[string]interface{}{"int": 7}, MsgpackCodec{})
+	c.Assert(err, IsNil)
+	aero.reNew("key", entry)
+
+	res, found := aero.Get("key")
+	c.Check(found, Equals, true)
+	c.Check(res.(map[string]interface{})["int"], Equals, int8(7))
+}
@@ -0,0 +1,181 @@
+package etcdaero
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor packs entry body. Its name is stored with entry,
+// so readers unpack body before IAeroBody.ReNew.
+type Compressor interface {
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Compressor names
+const (
+	CompressGzip   = "gzip"
+	CompressZstd   = "zstd"
+	CompressSnappy = "snappy"
+)
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{}
+)
+
+func init() {
+	RegisterCompressor(GzipCompressor{})
+	RegisterCompressor(ZstdCompressor{})
+	RegisterCompressor(SnappyCompressor{})
+}
+
+// RegisterCompressor adds compressor for readers, it replaces compressor with the same name.
+func RegisterCompressor(c Compressor) {
+	compressorsMu.Lock()
+	compressors[c.Name()] = c
+	compressorsMu.Unlock()
+}
+
+// CompressorByName returns registered compressor.
+func CompressorByName(name string) (Compressor, bool) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+
+	c, ok := compressors[name]
+	return c, ok
+}
+
+// GzipCompressor is compress/gzip.
+type GzipCompressor struct{}
+
+func (GzipCompressor) Name() string {
+	return CompressGzip
+}
+
+func (GzipCompressor) Compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// zstd encoder and decoder are safe for concurrent EncodeAll/DecodeAll
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func _zstdInit() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdErr
+}
+
+// ZstdCompressor is zstd by github.com/klauspost/compress.
+type ZstdCompressor struct{}
+
+func (ZstdCompressor) Name() string {
+	return CompressZstd
+}
+
+func (ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	if err := _zstdInit(); err != nil {
+		return nil, err
+	}
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+func (ZstdCompressor) Decompress(data []byte) ([]byte, error) {
+	if err := _zstdInit(); err != nil {
+		return nil, err
+	}
+	return zstdDecoder.DecodeAll(data, nil)
+}
+
+// SnappyCompressor is snappy block format.
+type SnappyCompressor struct{}
+
+func (SnappyCompressor) Name() string {
+	return CompressSnappy
+}
+
+func (SnappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (SnappyCompressor) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+// CompressStats shows how well compression works.
+type CompressStats struct {
+	// Count is the number of compressed or decompressed entries
+	Count int64
+	// RawBytes and PackedBytes are sizes of bodies before and after compression
+	RawBytes    int64
+	PackedBytes int64
+	// Duration is the total time of work
+	Duration time.Duration
+	// Last is the duration of the last work
+	Last time.Duration
+}
+
+// Ratio is packed size to raw size, 0 if nothing is counted.
+func (s CompressStats) Ratio() float64 {
+	if s.RawBytes == 0 {
+		return 0
+	}
+	return float64(s.PackedBytes) / float64(s.RawBytes)
+}
+
+// compressCounter collects CompressStats
+type compressCounter struct {
+	sync.Mutex
+	stats CompressStats
+}
+
+func (c *compressCounter) add(raw, packed int, d time.Duration) {
+	c.Lock()
+	c.stats.Count++
+	c.stats.RawBytes += int64(raw)
+	c.stats.PackedBytes += int64(packed)
+	c.stats.Duration += d
+	c.stats.Last = d
+	c.Unlock()
+}
+
+func (c *compressCounter) get() CompressStats {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.stats
+}
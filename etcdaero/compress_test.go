@@ -0,0 +1,62 @@
+package etcdaero
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	TestingT(t)
+}
+
+type CompressTestsSuite struct{}
+
+var _ = Suite(&CompressTestsSuite{})
+
+func (s *CompressTestsSuite) Test_RoundTrip(c *C) {
+	//c.Skip("Not now")
+
+	data := bytes.Repeat([]byte(`{"winnie":"pooh"}`), 100)
+
+	for _, name := range []string{CompressGzip, CompressZstd, CompressSnappy} {
+		comp, ok := CompressorByName(name)
+		c.Assert(ok, Equals, true)
+
+		packed, err := comp.Compress(data)
+		c.Assert(err, IsNil)
+		c.Check(len(packed) < len(data), Equals, true, Commentf("compressor %s", name))
+
+		raw, err := comp.Decompress(packed)
+		c.Assert(err, IsNil)
+		c.Check(raw, DeepEquals, data)
+	}
+}
+
+func (s *CompressTestsSuite) Test_Entry_Threshold(c *C) {
+	//c.Skip("Not now")
+
+	counter := &compressCounter{}
+
+	small := &EtcdAeroEntry{Body: []byte(`{"a":1}`)}
+	c.Assert(small.compress(GzipCompressor{}, 100, counter), IsNil)
+	c.Check(small.Compression, Equals, "")
+	c.Check(counter.get().Count, Equals, int64(0))
+
+	body := bytes.Repeat([]byte(`{"a":1}`), 100)
+	big := &EtcdAeroEntry{Body: body}
+	c.Assert(big.compress(GzipCompressor{}, 100, counter), IsNil)
+	c.Check(big.Compression, Equals, CompressGzip)
+
+	stats := counter.get()
+	c.Check(stats.Count, Equals, int64(1))
+	c.Check(stats.RawBytes, Equals, int64(len(body)))
+	c.Check(stats.Ratio() < 1, Equals, true)
+
+	c.Assert(big.decompress(counter), IsNil)
+	c.Check(big.Compression, Equals, "")
+	c.Check(big.Body, DeepEquals, body)
+
+	unknown := &EtcdAeroEntry{Body: body, Compression: "lz4"}
+	c.Check(unknown.decompress(counter), Equals, ErrUnknownCompressor)
+}
@@ -1,16 +1,39 @@
 package etcdaero
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"time"
 )
 
 // EtcdAeroEntry app response
 type EtcdAeroEntry struct {
 	Body []byte
+	// Codec is the name of Body codec, "" is JSON
+	Codec string
+	// Compression is the name of Body compressor, "" is not compressed
+	Compression string
+	// Hash is the content hash of not compressed Body, "" is unknown
+	Hash string
+	// Time is when the data is loaded from source, zero is unknown
+	Time time.Time
+	// Trace is W3C traceparent of the span which has written entry, "" is no trace
+	Trace string
 }
 
-var ErrIncorrectDataFormat = errors.New("Incorrect data format error")
+// bins of entry
+const (
+	binHash  = "hash"
+	binTime  = "time"
+	binTrace = "trace"
+)
+
+var (
+	ErrIncorrectDataFormat = errors.New("Incorrect data format error")
+	ErrUnknownCompressor   = errors.New("Unknown compressor error")
+)
 
 // NewEtcdAeroEntry
 func EmptyEtcdAeroEntry() *EtcdAeroEntry {
@@ -25,26 +48,125 @@ func NewEtcdAeroEntry(data map[string]interface{}) (*EtcdAeroEntry, error) {
 	body, err := json.Marshal(data)
 
 	return &EtcdAeroEntry{
-		Body: body,
+		Body:  body,
+		Codec: CodecJSON,
+	}, err
+}
+
+// NewEntryWithCodec encodes data with codec, nil is JSON
+func NewEntryWithCodec(data interface{}, codec Codec) (*EtcdAeroEntry, error) {
+	codec = codecOrDefault(codec)
+
+	body, err := codec.Marshal(data)
+
+	return &EtcdAeroEntry{
+		Body:  body,
+		Codec: codec.Name(),
 	}, err
 }
 
 // Import data from map[string]interface{} to EtcdAeroEntry
 func (entry *EtcdAeroEntry) Import(b map[string]interface{}) error {
 
-	if result, find := b["body"]; find {
-		if result, ok := result.([]byte); ok {
-			entry.Body = result
-			return nil
-		}
+	result, find := b["body"]
+	if !find {
+		return ErrIncorrectDataFormat
 	}
+	body, ok := result.([]byte)
+	if !ok {
+		return ErrIncorrectDataFormat
+	}
+
+	// old entries have no codec and compression
+	codec, _ := b["codec"].(string)
+	compression, _ := b["compress"].(string)
+	hash, _ := b[binHash].(string)
+	traceParent, _ := b[binTrace].(string)
 
-	return ErrIncorrectDataFormat
+	entry.Body = body
+	entry.Codec = codec
+	entry.Compression = compression
+	entry.Hash = hash
+	entry.Time = binToTime(b[binTime])
+	entry.Trace = traceParent
+	return nil
 }
 
 // Export data from EtcdAeroEntry to map[string]interface{}
 func (entry EtcdAeroEntry) Export() map[string]interface{} {
 	return map[string]interface{}{
-		"body": entry.Body,
+		"body":     entry.Body,
+		"codec":    entry.Codec,
+		"compress": entry.Compression,
+		binHash:    entry.Hash,
+		binTime:    entry._time(),
+		binTrace:   entry.Trace,
+	}
+}
+
+// binToTime parses time bin, zero is for old entries
+func binToTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	if s == "" {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+func (entry EtcdAeroEntry) _time() string {
+	if entry.Time.IsZero() {
+		return ""
 	}
+	return entry.Time.UTC().Format(time.RFC3339Nano)
+}
+
+// sum is the content hash of Body and Codec
+func (entry *EtcdAeroEntry) sum() string {
+	h := sha256.New()
+	h.Write([]byte(entry.Codec))
+	h.Write([]byte{0})
+	h.Write(entry.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// compress packs Body if it is not smaller than min
+func (entry *EtcdAeroEntry) compress(c Compressor, min int, counter *compressCounter) error {
+	if c == nil || entry.Compression != "" || len(entry.Body) < min {
+		return nil
+	}
+
+	start := time.Now()
+	body, err := c.Compress(entry.Body)
+	if err != nil {
+		return err
+	}
+	counter.add(len(entry.Body), len(body), time.Since(start))
+
+	entry.Body = body
+	entry.Compression = c.Name()
+	return nil
+}
+
+// decompress unpacks Body
+func (entry *EtcdAeroEntry) decompress(counter *compressCounter) error {
+	if entry.Compression == "" {
+		return nil
+	}
+
+	c, ok := CompressorByName(entry.Compression)
+	if !ok {
+		return ErrUnknownCompressor
+	}
+
+	start := time.Now()
+	body, err := c.Decompress(entry.Body)
+	if err != nil {
+		return err
+	}
+	counter.add(len(body), len(entry.Body), time.Since(start))
+
+	entry.Body = body
+	entry.Compression = ""
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	. "gopkg.in/check.v1"
 	"testing"
+	"time"
 )
 
 func TestEntry(t *testing.T) {
@@ -50,7 +51,7 @@ func (s *EntryTestsSuite) Test_Export(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(entry, NotNil)
 	c.Assert(res, NotNil)
-	c.Check(fmt.Sprintf("%s", res), Equals, `map[body:{"puper":"asdsadsadasd","super":1}]`)
+	c.Check(fmt.Sprintf("%s", res), Equals, `map[body:{"puper":"asdsadsadasd","super":1} codec:json compress: hash: time: trace:]`)
 }
 
 func (s *EntryTestsSuite) Test_Import(c *C) {
@@ -67,3 +68,46 @@ func (s *EntryTestsSuite) Test_Import(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(fmt.Sprintf("%s", entry.Body), Equals, `map[body:{"puper":"asdsadsadasd","super":1}]`)
 }
+
+func (s *EntryTestsSuite) Test_Import_Codec(c *C) {
+	//c.Skip("Not now")
+
+	entry := EmptyEtcdAeroEntry()
+	err := entry.Import(map[string]interface{}{
+		"body":  []byte(`body`),
+		"codec": CodecMsgpack,
+	})
+	c.Assert(err, IsNil)
+	c.Check(entry.Codec, Equals, CodecMsgpack)
+
+	c.Check(entry.Import(map[string]interface{}{"codec": CodecGob}), Equals, ErrIncorrectDataFormat)
+}
+
+func (s *EntryTestsSuite) Test_Import_Time(c *C) {
+	//c.Skip("Not now")
+
+	loaded := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	entry := EtcdAeroEntry{Body: []byte(`{}`), Time: loaded}
+	c.Check(entry.Export()["time"], Equals, "2020-01-02T03:04:05.000000006Z")
+
+	out := EmptyEtcdAeroEntry()
+	c.Assert(out.Import(entry.Export()), IsNil)
+	c.Check(out.Time.Equal(loaded), Equals, true)
+
+	// old entries have no time
+	c.Assert(out.Import(map[string]interface{}{"body": []byte(`{}`)}), IsNil)
+	c.Check(out.Time.IsZero(), Equals, true)
+}
+
+func (s *EntryTestsSuite) Test_NewEntryWithCodec(c *C) {
+	//c.Skip("Not now")
+
+	entry, err := NewEntryWithCodec(map[string]interface{}{"super": 1}, nil)
+	c.Assert(err, IsNil)
+	c.Check(entry.Codec, Equals, CodecJSON)
+	c.Check(string(entry.Body), Equals, `{"super":1}`)
+
+	entry, err = NewEntryWithCodec(map[string]interface{}{"super": 1}, MsgpackCodec{})
+	c.Assert(err, IsNil)
+	c.Check(entry.Codec, Equals, CodecMsgpack)
+}
@@ -0,0 +1,82 @@
+package etcdaero
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of Error, check them with errors.Is.
+var (
+	ErrLockFailed       = errors.New("lock backend failed")
+	ErrLockLost         = errors.New("lock is lost")
+	ErrLoaderFailed     = errors.New("loader failed")
+	ErrStoreUnavailable = errors.New("store is unavailable")
+	ErrEntryNotFound    = errors.New("entry is not found")
+	ErrDecodeFailed     = errors.New("decode failed")
+	ErrStaleToken       = errors.New("fencing token is stale")
+	ErrAlreadyStarted   = errors.New("EtcdAero is already started")
+)
+
+// Operations of Error
+const (
+	OpLock    = "lock"
+	OpRenew   = "renew"
+	OpLoad    = "load"
+	OpWrite   = "write"
+	OpPublish = "publish"
+	OpRead    = "read"
+	OpDecode  = "decode"
+	OpGet     = "get"
+	OpKey     = "key"
+	OpTTL     = "ttl"
+)
+
+// Error is the failure of operation Op with Key.
+// errors.Is matches both Kind and Err.
+type Error struct {
+	Op   string
+	Key  string
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	msg := e.Op + " " + e.Key
+	if e.Kind != nil {
+		msg += ": " + e.Kind.Error()
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// ErrorFunc gets errors of the background work
+type ErrorFunc func(err error)
+
+// newError makes Error, err may be nil
+func newError(op, key string, kind, err error) *Error {
+	return &Error{Op: op, Key: key, Kind: kind, Err: err}
+}
+
+// reportError calls f or logs err if f is nil
+func reportError(f ErrorFunc, l *logger, err error) {
+	if f != nil {
+		f(err)
+		return
+	}
+	l.error(err)
+}
+
+// keyName is the name of cache address in errors
+func keyName(key *StoreKey) string {
+	return fmt.Sprintf("%s.%s", key.Set, key.Pk)
+}
@@ -0,0 +1,45 @@
+package etcdaero
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	TestingT(t)
+}
+
+type ErrorsTestsSuite struct{}
+
+var _ = Suite(&ErrorsTestsSuite{})
+
+func (s *ErrorsTestsSuite) Test_Error(c *C) {
+	//c.Skip("Not now")
+
+	cause := errors.New("timeout")
+	err := error(newError(OpWrite, "set.pk", ErrStoreUnavailable, cause))
+
+	c.Check(err.Error(), Equals, "write set.pk: store is unavailable: timeout")
+	c.Check(errors.Is(err, ErrStoreUnavailable), Equals, true)
+	c.Check(errors.Is(err, cause), Equals, true)
+	c.Check(errors.Is(err, ErrLockLost), Equals, false)
+
+	var e *Error
+	c.Assert(errors.As(err, &e), Equals, true)
+	c.Check(e.Op, Equals, OpWrite)
+	c.Check(e.Key, Equals, "set.pk")
+
+	c.Check(newError(OpRenew, "key", ErrLockLost, nil).Error(), Equals, "renew key: lock is lost")
+}
+
+func (s *ErrorsTestsSuite) Test_ReportError(c *C) {
+	//c.Skip("Not now")
+
+	var got error
+	reportError(func(err error) { got = err }, newLogger(nil, nil, 0), ErrLockLost)
+	c.Check(got, Equals, ErrLockLost)
+
+	// nil hook logs
+	reportError(nil, newLogger(nil, nil, 0), ErrLockLost)
+}
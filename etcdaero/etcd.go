@@ -1,19 +1,37 @@
 package etcdaero
 
 import (
-	"fmt"
-	"hash/crc32"
-	"log"
-	"os"
-	"os/signal"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
 	"time"
 
-	"github.com/coreos/etcd/Godeps/_workspace/src/golang.org/x/net/context"
-	"github.com/coreos/etcd/client"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LoadFunc func([]interface{}) (map[string]interface{}, error)
 
+// entryLoader gets data from source and makes the cache entry
+type entryLoader func(ctx context.Context) (*EtcdAeroEntry, error)
+
+// loadFunc adapts LoadFunc with its params to entryLoader, nil codec is JSON
+func loadFunc(codec Codec, f LoadFunc, faces ...interface{}) entryLoader {
+	return func(ctx context.Context) (*EtcdAeroEntry, error) {
+		data, err := f(faces)
+		if err != nil {
+			return nil, err
+		}
+		return encodeEntry(ctx, data, codec)
+	}
+}
+
+// etcd API versions for Config.EtcdAPI
+const (
+	EtcdAPIv2 = "v2"
+	EtcdAPIv3 = "v3"
+)
+
 type Config struct {
 	AeroNamespace string
 	AeroPrefix    string
@@ -22,110 +40,368 @@ type Config struct {
 	EtcdPort      int
 	EtcdHost      string
 	EtcdEndpoints []string
+	// EtcdAPI selects the lock backend: EtcdAPIv2 (default) or EtcdAPIv3
+	EtcdAPI string
+
+	// NodeID is the identity of this node in the lock, DefaultNodeID() if it is empty.
+	// NodeMeta is stored in the lock value with it, for example version and zone.
+	NodeID   string
+	NodeMeta map[string]string
+
+	// Schedule is the policy of tries to take the lock: jitter, backoff and preference
+	Schedule Schedule
+
+	// Locker replaces the etcd lock backend if it is set
+	Locker Locker
+	// Store replaces the aerospike cache if it is set
+	Store Store
+	// Clock replaces the system time, for tests
+	Clock Clock
+
+	// Aero is the reader which stores data and is reloaded after put.
+	// The default AeroChecker is used if it is nil and it is made by New with the same store config,
+	// other store gets own AeroChecker, it is EtcdAero.Aero. Pass InitAeroChecker() here to use it.
+	Aero *AeroChecker
+
+	// Codec encodes loaded data, JSON by default
+	Codec Codec
+
+	// Compressor packs entries which are not smaller than CompressMin bytes,
+	// nil is no compression
+	Compressor  Compressor
+	CompressMin int
+
+	// ChunkSize splits bodies which are larger into several records, 0 is off
+	ChunkSize int
+
+	// Versioned writes each refresh under new generation and flips the pointer to it
+	Versioned bool
+
+	// Notifier tells readers about new data, the locker is used if it is Notifier
+	Notifier Notifier
+
+	// WriteRetries is the count of retries of failed cache write, 3 by default, -1 is no retries.
+	// The leader releases the lock if all of them fail.
+	WriteRetries int
+	// WriteBackoff is the pause before the first retry, it is doubled for each next one, 100 ms by default
+	WriteBackoff time.Duration
+
+	// OnError gets errors of the background work, they are logged if it is nil.
+	// The errors are *Error, check their kinds with errors.Is.
+	OnError ErrorFunc
+
+	// OnLost is called when the leader loses the lock, the in-flight load is canceled before.
+	// Loaders with context see it done, the result of LoadFunc is dropped.
+	OnLost func(key string)
+
+	// OnElected and OnRevoked are called when this node takes and gives up the lock,
+	// OnLeaderChanged gets the node id of any new leader, "" is no leader.
+	OnElected       func(key string)
+	OnRevoked       func(key string)
+	OnLeaderChanged func(key, node string)
+
+	// Logger writes structured logs, slog.Default() by default
+	Logger *slog.Logger
+	// LogInterval limits repeated messages to one per interval, 1 min by default
+	LogInterval time.Duration
+
+	// Metrics gets measurements, nil is no metrics
+	Metrics Metrics
+
+	// TracerProvider makes spans of refresh and reload, nil is no tracing
+	TracerProvider trace.TracerProvider
 }
 
-// 17 min 17 sec
-const defTimerTTL = 17 * 61 * time.Second
+const (
+	// 17 min 17 sec
+	defTimerTTL = 17 * 61 * time.Second
+
+	defWriteRetries = 3
+	defWriteBackoff = 100 * time.Millisecond
+)
 
 type EtcdAero struct {
 	etcdLockTTL time.Duration
 	timerTTL    time.Duration
+	renewTTL    time.Duration
 	sleepTTL    time.Duration
 	AeroTTL     time.Duration
-	client      client.Client
-	clientKey   client.KeysAPI
+	locker      Locker
+	notifier    Notifier
+	clock       Clock
+	log         *logger
+	metrics     Metrics
+	tracer      trace.Tracer
 	cfg         *Config
 	key         string
+	name        string
+	cacheKey    *StoreKey
+	node        Node
 	value       string
-	stopC       chan os.Signal
+	token       uint64
 	delta       float64
 	//Aero        *AeroSpikeClient
-	Aero      *AeroChecker
-	prevIndex uint64
+	Aero *AeroChecker
+
+	load entryLoader
+
+	compressed compressCounter
+
+	// state for Status
+	stMu      sync.Mutex
+	leader    bool
+	refreshed time.Time
+	lastErr   error
+	lastErrAt time.Time
+
+	mu        sync.Mutex
+	ownLocker bool
+	ownAero   bool
+	watching  bool // Aero watches the notifier for this EtcdAero
+	cancel    context.CancelFunc
+	done      chan struct{}
 }
 
-// New - creates new object
+// New - creates new object, Start runs it
 func New(key string, cfg *Config, f LoadFunc, faces ...interface{}) (*EtcdAero, error) {
+	return newEtcdAeroFrom(key, cfg, loadFunc(cfg.Codec, f, faces...))
+}
 
-	aeroClient, err := NewAeroSpikeClient(cfg)
+// newEtcdAeroFrom makes EtcdAero with aero and locker from cfg
+func newEtcdAeroFrom(key string, cfg *Config, load entryLoader) (*EtcdAero, error) {
+	aero, ownAero, err := aeroOf(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	aero := InitAeroChecker(aeroClient)
+	locker, ownLocker := cfg.Locker, false
+	if locker == nil {
+		if locker, err = NewLocker(cfg); err != nil {
+			if ownAero {
+				aero.Close()
+			}
+			return nil, err
+		}
+		ownLocker = true
+	}
 
+	ea, err := newEtcdAero(key, storeKey(key), cfg, aero, locker, load)
+	if err != nil {
+		if ownLocker {
+			locker.Close()
+		}
+		if ownAero {
+			aero.Close()
+		}
+		return nil, err
+	}
+	ea.ownLocker = ownLocker
+	ea.ownAero = ownAero
+
+	aero.Watch(ea.notifier)
+	ea.watching = ea.notifier != nil
+
+	return ea, nil
+}
+
+// aeroOf returns AeroChecker of cfg, own is true if it is made for one EtcdAero.
+// The default AeroChecker is made by the first config, it is shared with
+// the same store config only and its hooks are not changed by next ones.
+// Config.Store is the same if it is the same pointer.
+func aeroOf(cfg *Config) (aero *AeroChecker, own bool, err error) {
+	if cfg.Aero != nil {
+		return cfg.Aero, false, nil
+	}
+
+	defaultAeroMu.Lock()
+	defer defaultAeroMu.Unlock()
+
+	sc := storeConfigOf(cfg)
+	switch {
+	case defaultAero == nil:
+		if aero, err = newConfigAero(cfg); err != nil {
+			return nil, false, err
+		}
+		defaultAero, defaultStore = aero, &sc
+		return aero, false, nil
+	case defaultStore != nil && defaultStore.same(sc):
+		// the default of InitAeroChecker has no config, it is passed with Config.Aero only
+		return defaultAero, false, nil
+	}
+
+	if aero, err = newConfigAero(cfg); err != nil {
+		return nil, false, err
+	}
+	return aero, true, nil
+}
+
+// newConfigAero makes AeroChecker on the store of cfg with hooks of cfg
+func newConfigAero(cfg *Config) (*AeroChecker, error) {
+	store, err := NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	aero := NewAeroChecker(store)
+	aero.SetOnError(cfg.OnError)
+	aero.setLogger(configLogger(cfg))
+	aero.SetMetrics(cfg.Metrics)
+	aero.SetTracerProvider(cfg.TracerProvider)
+	aero.SetClock(cfg.Clock)
+
+	return aero, nil
+}
+
+// newEtcdAero makes EtcdAero on shared aero and locker
+func newEtcdAero(key string, cacheKey *StoreKey, cfg *Config, aero *AeroChecker, locker Locker, load entryLoader) (*EtcdAero, error) {
 	ea := &EtcdAero{
-		key:  key,
-		cfg:  cfg,
-		Aero: aero,
+		key:      key,
+		name:     key,
+		cacheKey: cacheKey,
+		cfg:      cfg,
+		Aero:     aero,
+		locker:   locker,
+		notifier: notifierOf(cfg, locker),
+		metrics:  metricsOf(cfg.Metrics),
+		tracer:   tracerOf(cfg.TracerProvider),
+		clock:    cfg.Clock,
+		load:     load,
+	}
+	if ea.clock == nil {
+		ea.clock = realClock{}
 	}
 
 	ea.SetTTL(defTimerTTL)
-	ea.stopC = make(chan os.Signal, 1)
-	signal.Notify(ea.stopC, os.Interrupt, os.Kill)
 
 	if err := ea._init(); err != nil {
-		aeroClient.Close()
 		return nil, err
 	}
 
-	ea._initCaching(f, faces...)
+	return ea, nil
+}
+
+// Start runs the refresh loop until ctx is done or Stop is called.
+func (ea *EtcdAero) Start(ctx context.Context) error {
+	ea.mu.Lock()
+	defer ea.mu.Unlock()
+
+	if ea.cancel != nil {
+		return ErrAlreadyStarted
+	}
+
+	ctx, ea.cancel = context.WithCancel(ctx)
+	ea.done = make(chan struct{})
+
+	go ea._initCaching(ctx, ea._ttls(), ea.done)
+
+	return nil
+}
+
+// Stop ends the refresh loop, releases the lock and waits for in-flight load.
+// It returns ctx error if ctx is done before.
+func (ea *EtcdAero) Stop(ctx context.Context) error {
+	ea.mu.Lock()
+	cancel, done := ea.cancel, ea.done
+	ea.cancel, ea.done = nil, nil
+	ea.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops EtcdAero and closes the locker and own AeroChecker if they are not from Config.
+// The notifier which New has added to the AeroChecker is removed from it.
+func (ea *EtcdAero) Close() error {
+	err := ea.Stop(context.Background())
+
+	// the shared AeroChecker must not watch the locker which is closed
+	ea.mu.Lock()
+	watching := ea.watching
+	ea.watching = false
+	ea.mu.Unlock()
+	if watching {
+		ea.Aero.Unwatch(ea.notifier)
+	}
+
+	if ea.ownLocker {
+		if errClose := ea.locker.Close(); err == nil {
+			err = errClose
+		}
+	}
+	if ea.ownAero {
+		ea.Aero.Close()
+	}
 
-	return ea, err
+	return err
 }
 
 /*
 	>>>>>>>>>>>>>>>>>>>>> CONFIG FUNCTION
 */
 
-func (ea *EtcdAero) SetTTL(timerTTL time.Duration) {
+// SetTTL sets the refresh interval and TTLs which are made from it, it works before Start only.
+// The running EtcdAero keeps its TTLs and returns ErrAlreadyStarted, Stop it and Start again
+// to change them. The error is sent to Config.OnError too, for callers which don't check it.
+func (ea *EtcdAero) SetTTL(timerTTL time.Duration) error {
+	ea.mu.Lock()
+	defer ea.mu.Unlock()
+
+	if ea.cancel != nil {
+		err := newError(OpTTL, ea.key, ErrAlreadyStarted, nil)
+		ea._error(err)
+		return err
+	}
 
 	ea.timerTTL = timerTTL
 	ea.etcdLockTTL = timerTTL * 3 / 2
+	ea.renewTTL = ea.etcdLockTTL / 3
 	ea.AeroTTL = timerTTL * 5
 
-	// randomize sleep ttl form 0.8 to 1.2 of original timerTTL / 2
-	crc32q := crc32.MakeTable(0xD5828281)
-	d := 1.0 + float64(int64(crc32.Checksum([]byte(ea.value), crc32q))%1000-500)/2500.0
-	ea.sleepTTL = time.Duration(float64(timerTTL) * d / 2)
+	// the pause between tries to take the lock, Config.Schedule randomizes it
+	ea.sleepTTL = timerTTL / 2
+
+	return nil
 }
 
+// Key changes the lock and cache key, it works before Start only.
 func (ea *EtcdAero) Key(key string) {
+	ea.mu.Lock()
+	defer ea.mu.Unlock()
+
+	if ea.cancel != nil {
+		ea._error(newError(OpKey, key, ErrAlreadyStarted, nil))
+		return
+	}
+
 	ea.key = key
+	ea.cacheKey = storeKey(key)
+	ea._logger()
 }
 
+// _logger makes logger with fields of dataset
+func (ea *EtcdAero) _logger() {
+	ea.log = configLogger(ea.cfg).with("key", ea.key, "node", ea.node.ID)
+}
+
+// _init sets the node identity, the lock value is node as JSON
 func (ea *EtcdAero) _init() error {
-	hostname, err := os.Hostname()
+	node, err := configNode(ea.cfg)
 	if err != nil {
 		return err
 	}
 
-	ea.value = fmt.Sprintf("%s%d", hostname, ea.cfg.EtcdPort)
-
-	initCfg := client.Config{
-		Endpoints: ea.cfg.EtcdEndpoints,
-		Transport: client.DefaultTransport,
-		// set timeout per request to fail fast when the target endpoint is unavailable
-		HeaderTimeoutPerRequest: 2 * time.Second,
-	}
-
-	ea.client, err = client.New(initCfg)
-	if err != nil {
-		log.Fatal(err)
-	}
-	ea.clientKey = client.NewKeysAPI(ea.client)
-
-	return nil
-}
-
-// _initCaching starts nexy job
-func (ea *EtcdAero) _initCaching(f LoadFunc, faces ...interface{}) error {
-	go func(ea *EtcdAero, f LoadFunc) {
-		for {
-			ea._make(f, faces...)
-			time.Sleep(ea.sleepTTL)
-		}
-	}(ea, f)
+	ea.node, ea.value = node, node.value()
+	ea._logger()
 
 	return nil
 }
@@ -134,116 +410,155 @@ func (ea *EtcdAero) _initCaching(f LoadFunc, faces ...interface{}) error {
 	CONFIG FUNCTION <<<<<<<<<<<<<<<<<<<<<
 */
 
-func (ea *EtcdAero) _updateResponse(resp *client.Response, err error) bool {
-	if err == nil {
-		ea.prevIndex = resp.Index
-		return true
-	}
-	ea.prevIndex = 0
-	return false
-}
-
-func (ea *EtcdAero) getLock() bool {
-	resp, err := ea.clientKey.Set(context.Background(), ea.key, ea.value, _setOptions("", 0, ea.etcdLockTTL))
-	return ea._updateResponse(resp, err)
+// CompressStats shows compression of stored entries.
+func (ea *EtcdAero) CompressStats() CompressStats {
+	return ea.compressed.get()
 }
 
-func (ea *EtcdAero) renewLock() bool {
-	resp, err := ea.clientKey.Set(context.Background(), ea.key, ea.value, _setOptions(ea.value, ea.prevIndex, ea.etcdLockTTL))
-	return ea._updateResponse(resp, err)
-}
+// _refresh loads data and puts it into the cache
+func (ea *EtcdAero) _refresh(ctx context.Context, t ttls) (err error) {
+	ctx, span := ea.tracer.Start(ctx, "etcdaero.refresh", trace.WithAttributes(attrKey.String(ea.key)))
+	defer func() { endSpan(span, err) }()
 
-func (ea *EtcdAero) releaseLock() {
-	// Ignore any errors
-	ea.clientKey.Delete(context.Background(), ea.key, _deleteOptions(ea.value))
-}
+	// the lock is lost or Stop is called before loading, the loader is not called
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 
-func (ea *EtcdAero) _make(f LoadFunc, faces ...interface{}) {
-	if !ea.getLock() {
-		return
+	start := ea.clock.Now()
+	loadCtx, loadSpan := startSpan(ctx, "etcdaero.load", attrKey.String(ea.key))
+	data, err := ea.load(loadCtx)
+	loaded := ea.clock.Now()
+	if err != nil {
+		err = newError(OpLoad, ea.key, ErrLoaderFailed, err)
+		endSpan(loadSpan, err)
+		ea.metrics.Load(ea.name, loaded.Sub(start), err)
+		return err
 	}
+	endSpan(loadSpan, nil)
+	ea.metrics.Load(ea.name, loaded.Sub(start), nil)
+	data.Time = loaded
 
-	data, err := f(faces)
-	if err == nil {
-		err = ea._putAero(data)
+	// the lock is lost or Stop is called while loading
+	if err = ctx.Err(); err != nil {
+		return err
 	}
 
-	if err != nil {
-		log.Printf("Error while pages caching %v", err)
-		ea.releaseLock()
-		return
+	if err = ea._putAero(ctx, data, t.aero); err != nil {
+		return err
 	}
 
-	for {
-		select {
-		case <-ea.stopC:
-			ea.releaseLock()
-		case <-time.Tick(ea.timerTTL):
-			data, err := f(faces)
+	ea.stMu.Lock()
+	ea.refreshed = ea.clock.Now()
+	ea.stMu.Unlock()
 
-			if err == nil {
-				err = ea._putAero(data)
-			}
+	ea.log.info("data is refreshed",
+		"version", data.Hash,
+		"size", len(data.Body),
+		"load", loaded.Sub(start),
+		"write", ea.clock.Now().Sub(loaded))
 
-			if err != nil {
-				ea.releaseLock()
-				return
-			}
+	return nil
+}
 
-			if !ea.renewLock() {
-				return
-			}
-		}
-	}
+// _error sends err to Config.OnError
+func (ea *EtcdAero) _error(err error) {
+	ea.stMu.Lock()
+	ea.lastErr, ea.lastErrAt = err, ea.clock.Now()
+	ea.stMu.Unlock()
+
+	reportError(ea.cfg.OnError, ea.log, err)
+}
+
+func (ea *EtcdAero) _setLeader(leader bool) {
+	ea.stMu.Lock()
+	ea.leader = leader
+	ea.stMu.Unlock()
 }
 
-func (ea *EtcdAero) _putAero(data map[string]interface{}) error {
+func (ea *EtcdAero) _putAero(ctx context.Context, pass *EtcdAeroEntry, ttl time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "etcdaero.put", attrKey.String(ea.key))
+	defer func() { endSpan(span, err) }()
 
-	pass, err := NewEtcdAeroEntry(data)
-	if err != nil {
-		return err
+	if pass.Compression == "" {
+		pass.Hash = pass.sum()
 	}
 
-	cacheKey := &AeroSpikeKey{
-		Set: ea.key,
-		Pk:  ea.key,
+	if err := pass.compress(ea.cfg.Compressor, ea.cfg.CompressMin, &ea.compressed); err != nil {
+		return newError(OpWrite, keyName(ea.cacheKey), nil, err)
 	}
 
-	ea.Aero.Put(cacheKey, pass, ea.AeroTTL)
+	span.SetAttributes(
+		attrSize.Int(len(pass.Body)),
+		attrCodec.String(pass.Codec),
+		attrCompression.String(pass.Compression),
+		attrVersion.String(pass.Hash))
+	pass.injectTrace(ctx)
+
+	if err := ea._write(ctx, pass, ttl); err != nil {
+		return err
+	}
 	ea.Aero.ReLoad()
 
+	ea._publish(pass.Hash)
+
 	return nil
 }
 
-func _setOptions(prevVal string, prevIndex uint64, ttl time.Duration) *client.SetOptions {
-
-	out := &client.SetOptions{
-		TTL:       ttl,
-		Dir:       false,
-		PrevExist: client.PrevNoExist,
+// _write puts entry into the cache with fencing token of the lock,
+// failed write is retried with backoff, the stale token is not.
+func (ea *EtcdAero) _write(ctx context.Context, pass *EtcdAeroEntry, ttl time.Duration) error {
+	retries := ea.cfg.WriteRetries
+	if retries == 0 {
+		retries = defWriteRetries
 	}
-
-	if prevVal != "" {
-		out.PrevValue = prevVal
-		out.PrevExist = client.PrevExist
+	backoff := ea.cfg.WriteBackoff
+	if backoff <= 0 {
+		backoff = defWriteBackoff
 	}
 
-	if prevIndex > 0 {
-		out.PrevIndex = prevIndex
-		out.PrevExist = client.PrevExist
-	}
+	for i := 0; ; i++ {
+		_, span := startSpan(ctx, "etcdaero.store.write",
+			attrKey.String(ea.key), attrSize.Int(len(pass.Body)), attrAttempt.Int(i+1))
+		err := ea.Aero.WriteFenced(ea.cacheKey, pass, ttl, ea.token)
+		endSpan(span, err)
+		if err == nil {
+			ea.metrics.Write(ea.name, len(pass.Body), nil)
+			return nil
+		}
+
+		if errors.Is(err, ErrStaleToken) {
+			err = newError(OpWrite, keyName(ea.cacheKey), ErrStaleToken, nil)
+			ea.metrics.Write(ea.name, len(pass.Body), err)
+			return err
+		}
+
+		err = newError(OpWrite, keyName(ea.cacheKey), ErrStoreUnavailable, err)
+		ea.metrics.Write(ea.name, len(pass.Body), err)
+		if i >= retries {
+			return err
+		}
+		ea._error(err)
 
-	return out
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ea.clock.After(backoff):
+		}
+		backoff *= 2
+	}
 }
 
-func _deleteOptions(prevVal string) *client.DeleteOptions {
-	out := &client.DeleteOptions{
-		Recursive: true,
-		Dir:       false,
+// _publish tells readers on other nodes about new data, they poll if it fails
+func (ea *EtcdAero) _publish(version string) {
+	if ea.notifier == nil {
+		return
 	}
-	if prevVal != "" {
-		out.PrevValue = prevVal
+	if version == "" {
+		version = newVersion()
 	}
 
-	return out
+	if err := ea.notifier.Publish(context.Background(), notifyKey(ea.cacheKey), version); err != nil {
+		ea._error(newError(OpPublish, keyName(ea.cacheKey), nil, err))
+	}
 }
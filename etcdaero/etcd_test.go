@@ -0,0 +1,148 @@
+package etcdaero
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestEtcd(t *testing.T) {
+	TestingT(t)
+}
+
+type EtcdTestsSuite struct{}
+
+var _ = Suite(&EtcdTestsSuite{})
+
+// nopStore is the empty Store which remembers Close
+type nopStore struct {
+	closed bool
+}
+
+func (s *nopStore) PutEntry(key *StoreKey, data IEntryData, ttl time.Duration) {}
+
+func (s *nopStore) WriteEntry(key *StoreKey, data IEntryData, ttl time.Duration) error {
+	return nil
+}
+
+func (s *nopStore) LoadEntry(key *StoreKey, buf IEntryData) (bool, error) { return false, nil }
+
+func (s *nopStore) DeleteEntry(key *StoreKey) error { return nil }
+
+func (s *nopStore) Close() { s.closed = true }
+
+// mapStore is the empty Store of value type which is not comparable
+type mapStore struct {
+	bins map[string]interface{}
+}
+
+func (s mapStore) PutEntry(key *StoreKey, data IEntryData, ttl time.Duration) {}
+
+func (s mapStore) WriteEntry(key *StoreKey, data IEntryData, ttl time.Duration) error {
+	return nil
+}
+
+func (s mapStore) LoadEntry(key *StoreKey, buf IEntryData) (bool, error) { return false, nil }
+
+func (s mapStore) DeleteEntry(key *StoreKey) error { return nil }
+
+func (s mapStore) Close() {}
+
+func (s *EtcdTestsSuite) Test_New_Store_Configs(c *C) {
+	//c.Skip("Not now")
+
+	_cleanDefaultAero()
+	defer _cleanDefaultAero()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+	table := NewLocalLockTable()
+
+	var got1, got3 []error
+	store1, store2 := &nopStore{}, &nopStore{}
+
+	cfg1 := &Config{Store: store1, Locker: NewLocalLocker(table), OnError: func(err error) { got1 = append(got1, err) }}
+	ea1, err := New("first", cfg1, f)
+	c.Assert(err, IsNil)
+	c.Check(ea1.Aero, Equals, DefaultAeroChecker())
+	c.Check(ea1.Aero.Conn, Equals, Store(store1))
+
+	// other store config gets own checker
+	cfg2 := &Config{Store: store2, ChunkSize: 1024, Locker: NewLocalLocker(table), OnError: func(err error) {}}
+	ea2, err := New("second", cfg2, f)
+	c.Assert(err, IsNil)
+	c.Check(ea2.Aero, Not(Equals), ea1.Aero)
+	_, chunked := ea2.Aero.Conn.(*ChunkedStore)
+	c.Check(chunked, Equals, true)
+
+	// the same store config shares the default checker and does not change its hooks
+	cfg3 := &Config{Store: store1, Locker: NewLocalLocker(table), OnError: func(err error) { got3 = append(got3, err) }}
+	ea3, err := New("third", cfg3, f)
+	c.Assert(err, IsNil)
+	c.Check(ea3.Aero, Equals, ea1.Aero)
+
+	ea3.Aero._error(errors.New("read failed"))
+	c.Check(got1, HasLen, 1)
+	c.Check(got3, HasLen, 0)
+
+	c.Assert(ea2.Close(), IsNil)
+	c.Check(store2.closed, Equals, true)
+
+	c.Assert(ea1.Close(), IsNil)
+	c.Assert(ea3.Close(), IsNil)
+	c.Check(store1.closed, Equals, false)
+}
+
+func (s *EtcdTestsSuite) Test_New_Store_Uncomparable(c *C) {
+	//c.Skip("Not now")
+
+	_cleanDefaultAero()
+	defer _cleanDefaultAero()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+	table := NewLocalLockTable()
+
+	ea1, err := New("first", &Config{Store: mapStore{bins: map[string]interface{}{}}, Locker: NewLocalLocker(table)}, f)
+	c.Assert(err, IsNil)
+	defer ea1.Close()
+	c.Check(ea1.Aero, Equals, DefaultAeroChecker())
+
+	// copies of value are not the same store
+	ea2, err := New("second", &Config{Store: mapStore{bins: map[string]interface{}{}}, Locker: NewLocalLocker(table)}, f)
+	c.Assert(err, IsNil)
+	defer ea2.Close()
+	c.Check(ea2.Aero, Not(Equals), ea1.Aero)
+}
+
+func (s *EtcdTestsSuite) Test_New_InitAeroChecker(c *C) {
+	//c.Skip("Not now")
+
+	_cleanDefaultAero()
+	defer _cleanDefaultAero()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+	table := NewLocalLockTable()
+
+	// the default has no config, it is not taken for any other store
+	def := InitAeroChecker(&nopStore{})
+	store := &nopStore{}
+	ea, err := New("key", &Config{Store: store, Locker: NewLocalLocker(table)}, f)
+	c.Assert(err, IsNil)
+	c.Check(ea.Aero, Not(Equals), def)
+	c.Check(ea.Aero.Conn, Equals, Store(store))
+
+	ea2, err := New("key2", &Config{Aero: def, Locker: NewLocalLocker(table)}, f)
+	c.Assert(err, IsNil)
+	c.Check(ea2.Aero, Equals, def)
+
+	c.Assert(ea.Close(), IsNil)
+	c.Check(store.closed, Equals, true)
+	c.Assert(ea2.Close(), IsNil)
+}
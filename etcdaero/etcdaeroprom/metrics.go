@@ -0,0 +1,169 @@
+// Package etcdaeroprom is etcdaero.Metrics on Prometheus.
+package etcdaeroprom
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "etcdaero"
+
+// Metrics keeps measurements of datasets, it is prometheus.Collector.
+type Metrics struct {
+	leader     *prometheus.GaugeVec
+	load       *prometheus.HistogramVec
+	writes     *prometheus.CounterVec
+	reads      *prometheus.CounterVec
+	size       *prometheus.GaugeVec
+	lastReload *prometheus.GaugeVec
+	age        *prometheus.Desc
+
+	mu     sync.Mutex
+	loaded map[string]time.Time
+	now    func() time.Time
+}
+
+var _ etcdaero.Metrics = (*Metrics)(nil)
+
+// New makes Metrics and registers it in reg, nil is prometheus.DefaultRegisterer.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		leader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "is_leader",
+			Help:      "1 if the node holds the lock of dataset.",
+		}, []string{"dataset"}),
+		load: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "load_duration_seconds",
+			Help:      "Duration of loader calls.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 15),
+		}, []string{"dataset", "result"}),
+		writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "writes_total",
+			Help:      "Cache writes by result.",
+		}, []string{"dataset", "result"}),
+		reads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reads_total",
+			Help:      "Cache reads by result.",
+		}, []string{"dataset", "result"}),
+		size: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "payload_bytes",
+			Help:      "Size of the last written or read entry.",
+		}, []string{"dataset", "op"}),
+		lastReload: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_reload_timestamp_seconds",
+			Help:      "Time of the last successful reload of reader.",
+		}, []string{"dataset"}),
+		age: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "data_age_seconds"),
+			"Age of the reader data since it is loaded from source.",
+			[]string{"dataset"}, nil),
+		loaded: map[string]time.Time{},
+		now:    time.Now,
+	}
+
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	if err := reg.Register(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *Metrics) Leader(key string, leader bool) {
+	v := 0.0
+	if leader {
+		v = 1
+	}
+	m.leader.WithLabelValues(key).Set(v)
+}
+
+func (m *Metrics) Load(key string, d time.Duration, err error) {
+	m.load.WithLabelValues(key, result(err)).Observe(d.Seconds())
+}
+
+func (m *Metrics) Write(key string, size int, err error) {
+	m.writes.WithLabelValues(key, result(err)).Inc()
+	if err == nil {
+		m.size.WithLabelValues(key, "write").Set(float64(size))
+	}
+}
+
+func (m *Metrics) Read(key string, size int, err error) {
+	m.reads.WithLabelValues(key, result(err)).Inc()
+	if err == nil {
+		m.size.WithLabelValues(key, "read").Set(float64(size))
+	}
+}
+
+func (m *Metrics) Reloaded(key string, loaded time.Time) {
+	now := m.now()
+	m.lastReload.WithLabelValues(key).Set(float64(now.UnixNano()) / 1e9)
+
+	if loaded.IsZero() {
+		return
+	}
+
+	m.mu.Lock()
+	m.loaded[key] = loaded
+	m.mu.Unlock()
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.leader.Describe(ch)
+	m.load.Describe(ch)
+	m.writes.Describe(ch)
+	m.reads.Describe(ch)
+	m.size.Describe(ch)
+	m.lastReload.Describe(ch)
+	ch <- m.age
+}
+
+// Collect counts data age at the moment of scrape.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.leader.Collect(ch)
+	m.load.Collect(ch)
+	m.writes.Collect(ch)
+	m.reads.Collect(ch)
+	m.size.Collect(ch)
+	m.lastReload.Collect(ch)
+
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, loaded := range m.loaded {
+		ch <- prometheus.MustNewConstMetric(m.age, prometheus.GaugeValue, now.Sub(loaded).Seconds(), key)
+	}
+}
+
+// result is the label of err
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, etcdaero.ErrStoreUnavailable):
+		return "store_unavailable"
+	case errors.Is(err, etcdaero.ErrStaleToken):
+		return "stale_token"
+	case errors.Is(err, etcdaero.ErrEntryNotFound):
+		return "not_found"
+	case errors.Is(err, etcdaero.ErrDecodeFailed):
+		return "decode_failed"
+	case errors.Is(err, etcdaero.ErrLoaderFailed):
+		return "loader_failed"
+	}
+	return "error"
+}
@@ -0,0 +1,171 @@
+package etcdaeroprom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero/etcdaerotest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "gopkg.in/check.v1"
+)
+
+func TestEtcdAeroProm(t *testing.T) {
+	TestingT(t)
+}
+
+type MetricsTestSuite struct{}
+
+var _ = Suite(&MetricsTestSuite{})
+
+func (s *MetricsTestSuite) Test_Metrics(c *C) {
+	//c.Skip("Not now")
+
+	m, err := New(prometheus.NewRegistry())
+	c.Assert(err, IsNil)
+
+	now := time.Unix(1000, 0)
+	m.now = func() time.Time { return now }
+
+	m.Leader("ds", true)
+	m.Load("ds", time.Second, nil)
+	m.Load("ds", time.Second, &etcdaero.Error{Kind: etcdaero.ErrLoaderFailed})
+	m.Write("ds", 10, &etcdaero.Error{Kind: etcdaero.ErrStoreUnavailable})
+	m.Write("ds", 10, nil)
+	m.Read("ds", 0, &etcdaero.Error{Kind: etcdaero.ErrEntryNotFound})
+	m.Read("ds", 7, nil)
+	m.Reloaded("ds", now.Add(-time.Minute))
+	m.Reloaded("ds", time.Time{})
+
+	c.Check(testutil.ToFloat64(m.leader.WithLabelValues("ds")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.writes.WithLabelValues("ds", "ok")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.writes.WithLabelValues("ds", "store_unavailable")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.reads.WithLabelValues("ds", "not_found")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.size.WithLabelValues("ds", "write")), Equals, 10.0)
+	c.Check(testutil.ToFloat64(m.size.WithLabelValues("ds", "read")), Equals, 7.0)
+	c.Check(testutil.ToFloat64(m.lastReload.WithLabelValues("ds")), Equals, 1000.0)
+	c.Check(testutil.CollectAndCount(m.load), Equals, 2)
+
+	// age grows until the next reload
+	now = now.Add(time.Minute)
+	expected := `
+# HELP etcdaero_data_age_seconds Age of the reader data since it is loaded from source.
+# TYPE etcdaero_data_age_seconds gauge
+etcdaero_data_age_seconds{dataset="ds"} 120
+`
+	c.Check(testutil.CollectAndCompare(m, strings.NewReader(expected), "etcdaero_data_age_seconds"), IsNil)
+
+	m.Leader("ds", false)
+	c.Check(testutil.ToFloat64(m.leader.WithLabelValues("ds")), Equals, 0.0)
+}
+
+func (s *MetricsTestSuite) Test_Result(c *C) {
+	//c.Skip("Not now")
+
+	c.Check(result(nil), Equals, "ok")
+	c.Check(result(&etcdaero.Error{Kind: etcdaero.ErrDecodeFailed}), Equals, "decode_failed")
+	c.Check(result(errors.New("other")), Equals, "error")
+}
+
+func (s *MetricsTestSuite) Test_Register(c *C) {
+	//c.Skip("Not now")
+
+	reg := prometheus.NewRegistry()
+	_, err := New(reg)
+	c.Assert(err, IsNil)
+
+	_, err = New(reg)
+	c.Check(err, NotNil)
+}
+
+func (s *MetricsTestSuite) Test_Cycle(c *C) {
+	//c.Skip("Not now")
+
+	m, err := New(prometheus.NewRegistry())
+	c.Assert(err, IsNil)
+
+	clock := etcdaerotest.NewClock()
+	store := etcdaerotest.NewStore(clock)
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+	aero.SetMetrics(m)
+
+	cfg := &etcdaero.Config{
+		Locker:  etcdaerotest.NewLocker(etcdaerotest.NewTable(clock)),
+		Clock:   clock,
+		Aero:    aero,
+		Metrics: m,
+	}
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("prom", cfg, f)
+	c.Assert(err, IsNil)
+	aero.StartReader("prom")
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	for i := 0; i < 1000; i++ {
+		if testutil.CollectAndCount(m, "etcdaero_data_age_seconds") > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.BlockUntil(1)
+
+	c.Check(testutil.ToFloat64(m.leader.WithLabelValues("prom")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.writes.WithLabelValues("prom", "ok")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.reads.WithLabelValues("prom", "ok")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.size.WithLabelValues("prom", "write")), Equals, 11.0)
+	c.Check(testutil.CollectAndCount(m, "etcdaero_data_age_seconds"), Equals, 1)
+
+	c.Assert(et.Close(), IsNil)
+	c.Check(testutil.ToFloat64(m.leader.WithLabelValues("prom")), Equals, 0.0)
+}
+
+func (s *MetricsTestSuite) Test_Manager(c *C) {
+	//c.Skip("Not now")
+
+	m, err := New(prometheus.NewRegistry())
+	c.Assert(err, IsNil)
+
+	clock := etcdaerotest.NewClock()
+	manager, err := etcdaero.NewManager(&etcdaero.Config{
+		Store:   etcdaerotest.NewStore(clock),
+		Locker:  etcdaerotest.NewLocker(etcdaerotest.NewTable(clock)),
+		Clock:   clock,
+		Metrics: m,
+	})
+	c.Assert(err, IsNil)
+	defer manager.Close()
+
+	_, err = manager.Register(etcdaero.Dataset{
+		Name:    "catalog",
+		LockKey: "locks/catalog",
+		Load: func(params []interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"ok": true}, nil
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(manager.Start(context.Background()), IsNil)
+
+	for i := 0; i < 1000; i++ {
+		if testutil.CollectAndCount(m, "etcdaero_data_age_seconds") > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.BlockUntil(1)
+
+	// all metrics are by dataset name, not by lock key
+	c.Check(testutil.ToFloat64(m.leader.WithLabelValues("catalog")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.writes.WithLabelValues("catalog", "ok")), Equals, 1.0)
+	c.Check(testutil.ToFloat64(m.reads.WithLabelValues("catalog", "ok")), Equals, 1.0)
+	c.Check(testutil.CollectAndCount(m.leader), Equals, 1)
+	c.Check(testutil.CollectAndCount(m.writes), Equals, 1)
+}
@@ -0,0 +1,108 @@
+package etcdaerotest
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type ChunkedTestSuite struct{}
+
+var _ = Suite(&ChunkedTestSuite{})
+
+type binsTest map[string]interface{}
+
+func (b binsTest) Export() map[string]interface{} { return b }
+
+func (b binsTest) Import(data map[string]interface{}) error {
+	for k, v := range data {
+		b[k] = v
+	}
+	return nil
+}
+
+func (s *ChunkedTestSuite) Test_Split_Join(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	cs := etcdaero.NewChunkedStore(store, 10)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "big"}
+
+	body := bytes.Repeat([]byte("0123456789"), 3)
+	body = append(body, 'x')
+	cs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: body, Codec: etcdaero.CodecJSON}, time.Minute)
+
+	// 4 chunks + manifest
+	c.Check(store.Len(), Equals, 5)
+
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(cs.LoadEntry(key, buf)), Equals, true)
+	c.Check(buf.Body, DeepEquals, body)
+	c.Check(buf.Codec, Equals, etcdaero.CodecJSON)
+
+	c.Assert(cs.DeleteEntry(key), IsNil)
+	c.Check(store.Len(), Equals, 0)
+}
+
+func (s *ChunkedTestSuite) Test_Small(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	cs := etcdaero.NewChunkedStore(store, 10)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "small"}
+
+	cs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("0123456789")}, time.Minute)
+	c.Check(store.Len(), Equals, 1)
+
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(cs.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "0123456789")
+
+	// entry which is written without ChunkedStore
+	store.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("old")}, time.Minute)
+	c.Assert(found(cs.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "old")
+}
+
+func (s *ChunkedTestSuite) Test_Broken(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	cs := etcdaero.NewChunkedStore(store, 4)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "big"}
+
+	cs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("winnie-the-pooh")}, time.Minute)
+
+	manifest := binsTest{"chunkver": nil}
+	c.Assert(found(store.LoadEntry(key, manifest)), Equals, true)
+	chunk := &etcdaero.StoreKey{Set: "set", Pk: fmt.Sprintf("big#%s#1", manifest["chunkver"])}
+
+	// bad checksum
+	store.PutEntry(chunk, binsTest{"body": []byte("PPPP")}, time.Minute)
+	c.Check(found(cs.LoadEntry(key, etcdaero.EmptyEtcdAeroEntry())), Equals, etcdaero.ErrChunkBroken)
+
+	// lost chunk
+	c.Assert(store.DeleteEntry(chunk), IsNil)
+	c.Check(found(cs.LoadEntry(key, etcdaero.EmptyEtcdAeroEntry())), Equals, etcdaero.ErrChunkBroken)
+
+	// the next version is whole again
+	cs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("winnie-the-pooh")}, time.Minute)
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(cs.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "winnie-the-pooh")
+}
+
+func (s *ChunkedTestSuite) Test_Config(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	res, err := etcdaero.NewStore(&etcdaero.Config{Store: store, ChunkSize: 1024})
+	c.Assert(err, IsNil)
+
+	cs, ok := res.(*etcdaero.ChunkedStore)
+	c.Assert(ok, Equals, true)
+	c.Check(cs.ChunkSize, Equals, 1024)
+}
@@ -0,0 +1,82 @@
+package etcdaerotest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the fake etcdaero.Clock, time moves only with Advance.
+type Clock struct {
+	sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	until time.Time
+	ch    chan time.Time
+}
+
+func NewClock() *Clock {
+	return &Clock{
+		now: time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (c *Clock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	w := &waiter{
+		until: c.now.Add(d),
+		ch:    make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		w.ch <- c.now
+		return w.ch
+	}
+
+	c.waiters = append(c.waiters, w)
+	return w.ch
+}
+
+// Advance moves time forward and fires all expired After.
+func (c *Clock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.now = c.now.Add(d)
+
+	left := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.until.After(c.now) {
+			left = append(left, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = left
+}
+
+// Waiters returns the count of pending After.
+func (c *Clock) Waiters() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return len(c.waiters)
+}
+
+// BlockUntil waits for n pending After, so Advance won't be missed.
+func (c *Clock) BlockUntil(n int) {
+	for c.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,49 @@
+package etcdaerotest
+
+import (
+	"context"
+	"strings"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type CompressTestSuite struct{}
+
+var _ = Suite(&CompressTestSuite{})
+
+func (s *CompressTestSuite) Test_Compressed_Cycle(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("packed", reader)
+
+	long := strings.Repeat("honey", 1000)
+	et, err := etcdaero.New("packed", &etcdaero.Config{
+		Locker:      NewLocker(NewTable(clock)),
+		Clock:       clock,
+		Aero:        aero,
+		Compressor:  etcdaero.ZstdCompressor{},
+		CompressMin: 1024,
+	}, func([]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"pooh": long}, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(et.Start(context.Background()), IsNil)
+	defer et.Close()
+
+	reader.waitFor(c, `{"pooh":"`+long+`"}`)
+
+	stored := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(store.LoadEntry(&etcdaero.StoreKey{Set: "packed", Pk: "packed"}, stored)), Equals, true)
+	c.Check(stored.Compression, Equals, etcdaero.CompressZstd)
+
+	c.Check(et.CompressStats().Count, Equals, int64(1))
+	c.Check(et.CompressStats().Ratio() < 0.1, Equals, true)
+	c.Check(aero.DecompressStats().Count > 0, Equals, true)
+}
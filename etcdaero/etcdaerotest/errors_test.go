@@ -0,0 +1,230 @@
+package etcdaerotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type ErrorsTestSuite struct{}
+
+var _ = Suite(&ErrorsTestSuite{})
+
+// errorsTest collects errors from OnError
+type errorsTest struct {
+	sync.Mutex
+	list []error
+}
+
+func (e *errorsTest) add(err error) {
+	e.Lock()
+	e.list = append(e.list, err)
+	e.Unlock()
+}
+
+func (e *errorsTest) waitFor(c *C, kind error) *etcdaero.Error {
+	for i := 0; i < 1000; i++ {
+		e.Lock()
+		for _, err := range e.list {
+			var out *etcdaero.Error
+			if errors.Is(err, kind) && errors.As(err, &out) {
+				e.Unlock()
+				return out
+			}
+		}
+		e.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	c.Fatalf("no error %s", kind)
+	return nil
+}
+
+func (s *ErrorsTestSuite) Test_Loader_Failed(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	got := &errorsTest{}
+
+	cfg := writeConfig(clock, store)
+	cfg.OnError = got.add
+	defer cfg.Aero.Close()
+
+	cause := errors.New("db is down")
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return nil, cause
+	}
+
+	et, err := etcdaero.New("loader", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	e := got.waitFor(c, etcdaero.ErrLoaderFailed)
+	c.Check(e.Op, Equals, etcdaero.OpLoad)
+	c.Check(e.Key, Equals, "loader")
+	c.Check(errors.Is(e, cause), Equals, true)
+
+	owner, _ := cfg.Locker.Owner(context.Background(), "loader")
+	c.Check(owner, Equals, "")
+}
+
+func (s *ErrorsTestSuite) Test_Store_Unavailable(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	got := &errorsTest{}
+
+	cfg := writeConfig(clock, store)
+	cfg.OnError = got.add
+	cfg.WriteRetries = -1
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+
+	store.Fail(1)
+
+	et, err := etcdaero.New("write", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	e := got.waitFor(c, etcdaero.ErrStoreUnavailable)
+	c.Check(e.Op, Equals, etcdaero.OpWrite)
+	c.Check(e.Key, Equals, "write.write")
+	c.Check(errors.Is(e, ErrWrite), Equals, true)
+}
+
+func (s *ErrorsTestSuite) Test_Lock_Lost(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	got := &errorsTest{}
+
+	cfg := writeConfig(clock, store)
+	cfg.OnError = got.add
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+
+	et, err := etcdaero.New("lost", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	// keepalive and refresh of the leader wait
+	clock.BlockUntil(2)
+	cfg.Locker.(*Locker).Expire("lost")
+	clock.Advance(17 * 61 * time.Second)
+
+	e := got.waitFor(c, etcdaero.ErrLockLost)
+	c.Check(e.Op, Equals, etcdaero.OpRenew)
+}
+
+func (s *ErrorsTestSuite) Test_Decode_Failed(c *C) {
+	//c.Skip("Not now")
+
+	got := &errorsTest{}
+	aero := etcdaero.NewAeroChecker(NewStore(nil))
+	defer aero.Close()
+	aero.SetOnError(got.add)
+
+	aero.StartReader("key")
+	aero.Put(&etcdaero.StoreKey{Set: "key", Pk: "key"}, &etcdaero.EtcdAeroEntry{Body: []byte("not json")}, time.Minute)
+	aero.ReLoad()
+
+	e := got.waitFor(c, etcdaero.ErrDecodeFailed)
+	c.Check(e.Op, Equals, etcdaero.OpDecode)
+	c.Check(e.Key, Equals, "key")
+
+	aero.StartReader("lost")
+	aero.ReLoad()
+
+	e = got.waitFor(c, etcdaero.ErrEntryNotFound)
+	c.Check(e.Key, Equals, "lost.lost")
+}
+
+func (s *ErrorsTestSuite) Test_Read_Failed(c *C) {
+	//c.Skip("Not now")
+
+	got := &errorsTest{}
+	store := NewStore(nil)
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+	aero.SetOnError(got.add)
+
+	aero.Put(&etcdaero.StoreKey{Set: "key", Pk: "key"}, &etcdaero.EtcdAeroEntry{Body: []byte("{}")}, time.Minute)
+	store.FailLoads(1)
+	aero.StartReader("key")
+	aero.ReLoad()
+
+	e := got.waitFor(c, etcdaero.ErrStoreUnavailable)
+	c.Check(e.Op, Equals, etcdaero.OpRead)
+	c.Check(e.Key, Equals, "key.key")
+	c.Check(errors.Is(e, ErrRead), Equals, true)
+
+	got.Lock()
+	defer got.Unlock()
+	for _, err := range got.list {
+		c.Check(errors.Is(err, etcdaero.ErrEntryNotFound), Equals, false)
+	}
+}
+
+func (s *ErrorsTestSuite) Test_Put_Failed(c *C) {
+	//c.Skip("Not now")
+
+	got := &errorsTest{}
+	store := NewStore(nil)
+	wrapped, err := etcdaero.NewStore(&etcdaero.Config{Store: store, ChunkSize: 4, Versioned: true, OnError: got.add})
+	c.Assert(err, IsNil)
+
+	store.Fail(1)
+	wrapped.PutEntry(&etcdaero.StoreKey{Set: "put", Pk: "put"}, &etcdaero.EtcdAeroEntry{Body: []byte("long body")}, time.Minute)
+
+	e := got.waitFor(c, etcdaero.ErrStoreUnavailable)
+	c.Check(e.Op, Equals, etcdaero.OpWrite)
+	c.Check(e.Key, Equals, "put.put")
+	c.Check(errors.Is(e, ErrWrite), Equals, true)
+}
+
+func (s *ErrorsTestSuite) Test_SetTTL_Started(c *C) {
+	//c.Skip("Not now")
+
+	got := &errorsTest{}
+	aero := etcdaero.NewAeroChecker(NewStore(nil))
+	defer aero.Close()
+
+	// real clock, the running loops must not race with SetTTL
+	cfg := &etcdaero.Config{Locker: NewLocker(nil), Aero: aero, OnError: got.add}
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("ttl", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+
+	c.Assert(et.SetTTL(time.Hour), IsNil)
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	err = et.SetTTL(time.Second)
+	c.Check(errors.Is(err, etcdaero.ErrAlreadyStarted), Equals, true)
+
+	e := got.waitFor(c, etcdaero.ErrAlreadyStarted)
+	c.Check(e.Op, Equals, etcdaero.OpTTL)
+	c.Check(e.Key, Equals, "ttl")
+
+	// it works again after Stop
+	c.Assert(et.Stop(context.Background()), IsNil)
+	c.Check(et.SetTTL(time.Second), IsNil)
+}
@@ -0,0 +1,237 @@
+package etcdaerotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+func TestEtcdAeroTest(t *testing.T) {
+	TestingT(t)
+}
+
+type EtcdAeroTestSuite struct{}
+
+var _ = Suite(&EtcdAeroTestSuite{})
+
+type readerTest struct {
+	sync.Mutex
+	list []string
+}
+
+func (r *readerTest) Get(data []interface{}) (interface{}, bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if len(r.list) == 0 {
+		return nil, false, nil
+	}
+	return r.list[len(r.list)-1], true, nil
+}
+
+func (r *readerTest) ReNew(data []byte) error {
+	r.Lock()
+	r.list = append(r.list, string(data))
+	r.Unlock()
+	return nil
+}
+
+func (r *readerTest) waitFor(c *C, body string) {
+	for i := 0; i < 1000; i++ {
+		if res, ok, _ := r.Get(nil); ok && res == body {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Fatalf("reader has not got %s", body)
+}
+
+// found is the result of LoadEntry for checks, the error if the load has failed
+func found(ok bool, err error) interface{} {
+	if err != nil {
+		return err
+	}
+	return ok
+}
+
+func (s *EtcdAeroTestSuite) Test_Clock(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	start := clock.Now()
+
+	ch := clock.After(time.Second)
+	c.Check(clock.Waiters(), Equals, 1)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-ch:
+		c.Fatal("fired too early")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	c.Check(<-ch, Equals, start.Add(time.Second))
+	c.Check(clock.Waiters(), Equals, 0)
+}
+
+func (s *EtcdAeroTestSuite) Test_Store_TTL(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "pk"}
+
+	entry, err := etcdaero.NewEtcdAeroEntry(map[string]interface{}{"a": 1})
+	c.Assert(err, IsNil)
+	store.PutEntry(key, entry, time.Minute)
+
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Check(found(store.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, `{"a":1}`)
+
+	clock.Advance(time.Minute)
+	c.Check(found(store.LoadEntry(key, etcdaero.EmptyEtcdAeroEntry())), Equals, false)
+
+	store.PutEntry(key, entry, time.Minute)
+	c.Assert(store.DeleteEntry(key), IsNil)
+	c.Check(found(store.LoadEntry(key, etcdaero.EmptyEtcdAeroEntry())), Equals, false)
+}
+
+func (s *EtcdAeroTestSuite) Test_Locker_Expire(c *C) {
+	//c.Skip("Not now")
+
+	ctx := context.Background()
+	clock := NewClock()
+	table := NewTable(clock)
+	node1, node2 := NewLocker(table), NewLocker(table)
+
+	ok, _ := node1.Acquire(ctx, "key", "node1", time.Minute)
+	c.Check(ok, Equals, true)
+
+	clock.Advance(time.Minute)
+	ok, _ = node1.Renew(ctx, "key", "node1", time.Minute)
+	c.Check(ok, Equals, false)
+
+	ok, _ = node2.Acquire(ctx, "key", "node2", time.Minute)
+	c.Check(ok, Equals, true)
+
+	node1.Expire("key")
+	owner, _ := node1.Owner(ctx, "key")
+	c.Check(owner, Equals, "")
+}
+
+func (s *EtcdAeroTestSuite) Test_New_Cycle(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	locker := NewLocker(NewTable(clock))
+
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	cfg := &etcdaero.Config{
+		Locker: locker,
+		Clock:  clock,
+		Aero:   aero,
+	}
+
+	reader := &readerTest{}
+	aero.StartReader("cycle", reader)
+
+	count := 0
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		count++
+		return map[string]interface{}{"count": count, "param": params[0]}, nil
+	}
+
+	et, err := etcdaero.New("cycle", cfg, f, "pooh")
+	c.Assert(err, IsNil)
+	c.Assert(et.Start(context.Background()), IsNil)
+	c.Check(et.Start(context.Background()), Equals, etcdaero.ErrAlreadyStarted)
+
+	reader.waitFor(c, `{"count":1,"param":"pooh"}`)
+
+	owner, _ := locker.Owner(context.Background(), "cycle")
+	c.Check(owner, Not(Equals), "")
+
+	// the next refresh
+	clock.BlockUntil(1)
+	clock.Advance(17 * 61 * time.Second)
+	reader.waitFor(c, `{"count":2,"param":"pooh"}`)
+	c.Check(store.Puts(), Equals, 2)
+
+	c.Assert(et.Stop(context.Background()), IsNil)
+	owner, _ = locker.Owner(context.Background(), "cycle")
+	c.Check(owner, Equals, "")
+
+	// stopped loop does not refresh
+	clock.Advance(17 * 61 * time.Second)
+	c.Check(store.Puts(), Equals, 2)
+	c.Assert(et.Close(), IsNil)
+}
+
+func (s *EtcdAeroTestSuite) Test_AeroChecker_Instances(c *C) {
+	//c.Skip("Not now")
+
+	store1, store2 := NewStore(nil), NewStore(nil)
+	aero1, aero2 := etcdaero.NewAeroChecker(store1), etcdaero.NewAeroChecker(store2)
+	defer aero1.Close()
+	defer aero2.Close()
+
+	reader1, reader2 := &readerTest{}, &readerTest{}
+	aero1.StartReader("key", reader1)
+	aero2.StartReader("key", reader2)
+
+	entry1, _ := etcdaero.NewEtcdAeroEntry(map[string]interface{}{"store": 1})
+	entry2, _ := etcdaero.NewEtcdAeroEntry(map[string]interface{}{"store": 2})
+	aero1.Put(&etcdaero.StoreKey{Set: "key", Pk: "key"}, entry1, time.Minute)
+	aero2.Put(&etcdaero.StoreKey{Set: "key", Pk: "key"}, entry2, time.Minute)
+	aero1.ReLoad()
+	aero2.ReLoad()
+
+	reader1.waitFor(c, `{"store":1}`)
+	reader2.waitFor(c, `{"store":2}`)
+}
+
+func (s *EtcdAeroTestSuite) Test_StopReader(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("key", reader)
+
+	entry, _ := etcdaero.NewEtcdAeroEntry(map[string]interface{}{"a": 1})
+	aero.Put(&etcdaero.StoreKey{Set: "key", Pk: "key"}, entry, time.Minute)
+	aero.ReLoad()
+	reader.waitFor(c, `{"a":1}`)
+
+	aero.StopReader("key")
+	_, ok := aero.Get("key")
+	c.Check(ok, Equals, false)
+
+	// the name is free again
+	aero.StartReader("key", reader)
+	_, ok = aero.Get("key")
+	c.Check(ok, Equals, true)
+}
+
+func (s *EtcdAeroTestSuite) Test_ReLoad_Closed(c *C) {
+	//c.Skip("Not now")
+
+	aero := etcdaero.NewAeroChecker(NewStore(nil))
+	aero.Close()
+
+	// nobody loads, ReLoad does not wait
+	for i := 0; i < 1000; i++ {
+		aero.ReLoad()
+	}
+}
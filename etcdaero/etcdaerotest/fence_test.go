@@ -0,0 +1,129 @@
+package etcdaerotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type FenceTestSuite struct{}
+
+var _ = Suite(&FenceTestSuite{})
+
+func (s *FenceTestSuite) Test_Stores(c *C) {
+	//c.Skip("Not now")
+
+	stores := []etcdaero.Store{
+		NewStore(nil),
+		etcdaero.NewChunkedStore(NewStore(nil), 4),
+		etcdaero.NewVersionedStore(NewStore(nil)),
+	}
+
+	key := &etcdaero.StoreKey{Set: "fence", Pk: "fence"}
+	data := &etcdaero.EtcdAeroEntry{Body: []byte("0123456789"), Codec: "json"}
+
+	for _, store := range stores {
+		fs, ok := store.(etcdaero.FencedStore)
+		c.Assert(ok, Equals, true)
+
+		c.Check(fs.WriteFenced(key, data, time.Minute, 2), IsNil)
+		c.Check(fs.WriteFenced(key, data, time.Minute, 2), IsNil)
+		c.Check(fs.WriteFenced(key, data, time.Minute, 1), Equals, etcdaero.ErrStaleToken)
+
+		// the plain write keeps the token
+		c.Check(store.WriteEntry(key, data, time.Minute), IsNil)
+		c.Check(fs.WriteFenced(key, data, time.Minute, 1), Equals, etcdaero.ErrStaleToken)
+		c.Check(fs.WriteFenced(key, data, time.Minute, 3), IsNil)
+	}
+}
+
+func (s *FenceTestSuite) Test_Reset_Token(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	got := &errorsTest{}
+
+	cfg := writeConfig(clock, store)
+	cfg.OnError = got.add
+	defer cfg.Aero.Close()
+
+	// the entry of the old locker has the token which is greater than the ones of the new locker
+	key := &etcdaero.StoreKey{Set: "reset", Pk: "reset"}
+	data, err := etcdaero.NewEtcdAeroEntry(map[string]interface{}{"locker": "old"})
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Aero.WriteFenced(key, data, 24*time.Hour, 100), IsNil)
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"locker": "new"}, nil
+	}
+
+	et, err := etcdaero.New("reset", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	got.waitFor(c, etcdaero.ErrStaleToken)
+	c.Check(store.Token(key), Equals, uint64(100))
+
+	c.Assert(et.ResetToken(), IsNil)
+	c.Check(store.Token(key), Equals, uint64(0))
+
+	// the next leader writes with its own token
+	for i := 0; i < 1000 && store.Token(key) == 0; i++ {
+		clock.Advance(time.Hour)
+		time.Sleep(time.Millisecond)
+	}
+	c.Check(store.Token(key) > 0 && store.Token(key) < 100, Equals, true)
+}
+
+func (s *FenceTestSuite) Test_Stale_Leader(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	got := &errorsTest{}
+
+	cfg := writeConfig(clock, store)
+	cfg.OnError = got.add
+	defer cfg.Aero.Close()
+
+	reader := &readerTest{}
+	cfg.Aero.StartReader("stale", reader)
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"node": "old"}, nil
+	}
+
+	et, err := etcdaero.New("stale", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	reader.waitFor(c, `{"node":"old"}`)
+	key := &etcdaero.StoreKey{Set: "stale", Pk: "stale"}
+	c.Check(store.Token(key), Equals, uint64(1))
+
+	// the old leader stalls and does not see the new leader with greater token
+	data, err := etcdaero.NewEtcdAeroEntry(map[string]interface{}{"node": "new"})
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Aero.WriteFenced(key, data, time.Hour, 2), IsNil)
+	cfg.Aero.ReLoad()
+	reader.waitFor(c, `{"node":"new"}`)
+
+	// the late write of the old leader is rejected, its keepalive and refresh wait
+	clock.BlockUntil(2)
+	clock.Advance(17 * 61 * time.Second)
+
+	e := got.waitFor(c, etcdaero.ErrStaleToken)
+	c.Check(e.Op, Equals, etcdaero.OpWrite)
+	c.Check(store.Token(key), Equals, uint64(2))
+
+	cfg.Aero.ReLoad()
+	reader.waitFor(c, `{"node":"new"}`)
+
+	// the stale leader steps down
+	c.Check(waitOwner(cfg.Locker, "stale", ""), Equals, "")
+}
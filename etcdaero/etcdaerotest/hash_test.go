@@ -0,0 +1,160 @@
+package etcdaerotest
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type HashTestSuite struct{}
+
+var _ = Suite(&HashTestSuite{})
+
+func waitSkipped(c *C, aero *etcdaero.AeroChecker, count int64) {
+	for i := 0; i < 1000; i++ {
+		if aero.SkippedReloads() >= count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Fatalf("skipped reloads %d, expected %d", aero.SkippedReloads(), count)
+}
+
+func (s *HashTestSuite) Test_Skip_Unchanged(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	cfg := &etcdaero.Config{
+		Locker: NewLocker(NewTable(clock)),
+		Clock:  clock,
+		Aero:   aero,
+	}
+
+	reader := &readerTest{}
+	aero.StartReader("hash", reader)
+
+	body := map[string]interface{}{"body": 1}
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return body, nil
+	}
+
+	et, err := etcdaero.New("hash", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	reader.waitFor(c, `{"body":1}`)
+
+	// data is the same, it is written but not reloaded
+	clock.BlockUntil(1)
+	clock.Advance(17 * 61 * time.Second)
+	clock.BlockUntil(1)
+	c.Check(store.Puts(), Equals, 2)
+
+	skipped := aero.SkippedReloads()
+	aero.ReLoad()
+	waitSkipped(c, aero, skipped+1)
+
+	// new data is reloaded
+	body = map[string]interface{}{"body": 2}
+	clock.Advance(17 * 61 * time.Second)
+	reader.waitFor(c, `{"body":2}`)
+
+	reader.Lock()
+	defer reader.Unlock()
+	c.Check(reader.list, DeepEquals, []string{`{"body":1}`, `{"body":2}`})
+}
+
+func (s *HashTestSuite) Test_Skip_Chunked(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	aero := etcdaero.NewAeroChecker(etcdaero.NewChunkedStore(store, 4))
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("key", reader)
+
+	key := &etcdaero.StoreKey{Set: "key", Pk: "key"}
+	aero.Put(key, &etcdaero.EtcdAeroEntry{Body: []byte("long body"), Hash: "h1"}, time.Minute)
+	aero.ReLoad()
+	reader.waitFor(c, "long body")
+
+	aero.ReLoad()
+	waitSkipped(c, aero, 1)
+
+	aero.Put(key, &etcdaero.EtcdAeroEntry{Body: []byte("new long body"), Hash: "h2"}, time.Minute)
+	aero.ReLoad()
+	reader.waitFor(c, "new long body")
+
+	reader.Lock()
+	defer reader.Unlock()
+	c.Check(reader.list, DeepEquals, []string{"long body", "new long body"})
+}
+
+func (s *HashTestSuite) Test_No_Hash(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("key", reader)
+
+	// old entries have no hash, they are reloaded always
+	key := &etcdaero.StoreKey{Set: "key", Pk: "key"}
+	aero.Put(key, &etcdaero.EtcdAeroEntry{Body: []byte("old")}, time.Minute)
+	aero.ReLoad()
+	aero.ReLoad()
+
+	for i := 0; i < 1000; i++ {
+		reader.Lock()
+		n := len(reader.list)
+		reader.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	reader.Lock()
+	defer reader.Unlock()
+	c.Check(reader.list, DeepEquals, []string{"old", "old"})
+	c.Check(aero.SkippedReloads(), Equals, int64(0))
+}
+
+// countStore counts reads of Store
+type countStore struct {
+	*Store
+	loads int64
+}
+
+func (s *countStore) LoadEntry(key *etcdaero.StoreKey, buf etcdaero.IEntryData) (bool, error) {
+	atomic.AddInt64(&s.loads, 1)
+	return s.Store.LoadEntry(key, buf)
+}
+
+func (s *HashTestSuite) Test_Not_Found_Once(c *C) {
+	//c.Skip("Not now")
+
+	got := &errorsTest{}
+	store := &countStore{Store: NewStore(nil)}
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+	aero.SetOnError(got.add)
+
+	aero.StartReader("lost")
+	aero.ReLoad()
+
+	got.waitFor(c, etcdaero.ErrEntryNotFound)
+	c.Check(atomic.LoadInt64(&store.loads), Equals, int64(1))
+}
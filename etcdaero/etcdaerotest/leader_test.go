@@ -0,0 +1,288 @@
+package etcdaerotest
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type LeaderTestSuite struct{}
+
+var _ = Suite(&LeaderTestSuite{})
+
+// renew interval of default ttl: lock ttl / 3
+const renewTest = 17 * 61 * time.Second / 2
+
+func (s *LeaderTestSuite) Test_Slow_Loader(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	defer cfg.Aero.Close()
+
+	release := make(chan struct{})
+	ds, err := etcdaero.NewDataset("slow", cfg, func(ctx context.Context) (string, error) {
+		<-release
+		return "loaded", nil
+	})
+	c.Assert(err, IsNil)
+	defer ds.Close()
+	c.Assert(ds.Start(context.Background()), IsNil)
+
+	// the load is longer than the lock ttl, keepalive holds the lock
+	for i := 0; i < 4; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(renewTest)
+	}
+	clock.BlockUntil(1)
+
+	owner, _ := cfg.Locker.Owner(context.Background(), "slow")
+	c.Check(owner, Not(Equals), "")
+
+	close(release)
+	data := waitTyped(c, ds, func(string) bool { return true })
+	c.Check(data, Equals, "loaded")
+	c.Check(store.Puts(), Equals, 1)
+}
+
+func (s *LeaderTestSuite) Test_Lost(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	got := &errorsTest{}
+	lost := make(chan string, 1)
+
+	cfg := writeConfig(clock, store)
+	cfg.OnError = got.add
+	cfg.OnLost = func(key string) { lost <- key }
+	defer cfg.Aero.Close()
+
+	canceled := make(chan error, 1)
+	ds, err := etcdaero.NewDataset("lost", cfg, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return "", ctx.Err()
+	})
+	c.Assert(err, IsNil)
+	defer ds.Close()
+	c.Assert(ds.Start(context.Background()), IsNil)
+
+	clock.BlockUntil(1)
+	status := ds.Status(context.Background())
+	c.Check(status.Leader, Equals, true)
+
+	// other node takes the lock while this one loads
+	locker := cfg.Locker.(*Locker)
+	locker.Expire("lost")
+	ok, _ := NewLocker(locker.Table).Acquire(context.Background(), "lost", "other", time.Hour)
+	c.Assert(ok, Equals, true)
+	clock.Advance(renewTest)
+
+	c.Check(<-canceled, Equals, context.Canceled)
+	c.Check(<-lost, Equals, "lost")
+
+	e := got.waitFor(c, etcdaero.ErrLockLost)
+	c.Check(e.Op, Equals, etcdaero.OpRenew)
+
+	status = ds.Status(context.Background())
+	c.Check(status.Leader, Equals, false)
+	c.Check(status.LockHolder, Equals, "other")
+	c.Check(store.Puts(), Equals, 0)
+}
+
+func (s *LeaderTestSuite) Test_Hooks(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	elected, revoked := make(chan string, 1), make(chan string, 1)
+	changed := make(chan string, 10)
+
+	cfg := writeConfig(clock, store)
+	cfg.OnElected = func(key string) { elected <- key }
+	cfg.OnRevoked = func(key string) { revoked <- key }
+	cfg.OnLeaderChanged = func(key, node string) { changed <- node }
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("hooks", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Check(et.IsLeader(), Equals, false)
+
+	// other node observes the leader
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	follower, err := etcdaero.New("hooks", &etcdaero.Config{Locker: cfg.Locker, Clock: clock, Aero: cfg.Aero}, f)
+	c.Assert(err, IsNil)
+	defer follower.Close()
+	observed := follower.Observe(ctx)
+	c.Check(<-observed, Equals, "")
+
+	c.Assert(et.Start(context.Background()), IsNil)
+	c.Check(<-elected, Equals, "hooks")
+	c.Check(et.IsLeader(), Equals, true)
+
+	node, err := et.Leader(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(node, Not(Equals), "")
+	c.Check(<-observed, Equals, node)
+
+	// the watch may start after acquire
+	got := <-changed
+	if got == "" {
+		got = <-changed
+	}
+	c.Check(got, Equals, node)
+
+	c.Assert(et.Stop(context.Background()), IsNil)
+	c.Check(<-revoked, Equals, "hooks")
+	c.Check(et.IsLeader(), Equals, false)
+	c.Check(<-observed, Equals, "")
+}
+
+func (s *LeaderTestSuite) Test_Node(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	cfg.NodeID = "worker-1"
+	cfg.NodeMeta = map[string]string{"zone": "eu-1"}
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("node", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Check(et.NodeID(), Equals, "worker-1")
+
+	// the worker in the same process has own identity
+	other, err := etcdaero.New("node", &etcdaero.Config{Locker: cfg.Locker, Clock: clock, Aero: cfg.Aero, NodeID: "worker-2"}, f)
+	c.Assert(err, IsNil)
+	defer other.Close()
+
+	c.Assert(et.Start(context.Background()), IsNil)
+	c.Check(waitOwner(cfg.Locker, "node", `{"id":"worker-1","meta":{"zone":"eu-1"}}`), Equals, `{"id":"worker-1","meta":{"zone":"eu-1"}}`)
+	c.Assert(other.Start(context.Background()), IsNil)
+
+	leader, err := other.LeaderNode(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(leader, DeepEquals, etcdaero.Node{ID: "worker-1", Meta: map[string]string{"zone": "eu-1"}})
+
+	st := other.Status(context.Background())
+	c.Check(st.Node, Equals, "worker-2")
+	c.Check(st.Leader, Equals, false)
+	c.Check(st.LockHolder, Equals, "worker-1")
+	c.Check(st.LockHolderMeta, DeepEquals, map[string]string{"zone": "eu-1"})
+
+	// worker-2 can't renew the lock of worker-1
+	ok, err := cfg.Locker.Renew(context.Background(), "node", `{"id":"worker-2"}`, time.Hour)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+}
+
+func (s *LeaderTestSuite) Test_Standby(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	cfg.NodeID = "preferred"
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	standby, err := etcdaero.New("standby", &etcdaero.Config{
+		Locker:   cfg.Locker,
+		Clock:    clock,
+		Aero:     cfg.Aero,
+		NodeID:   "standby",
+		Schedule: etcdaero.Schedule{Standby: time.Minute},
+	}, f)
+	c.Assert(err, IsNil)
+	defer standby.Close()
+
+	preferred, err := etcdaero.New("standby", cfg, f)
+	c.Assert(err, IsNil)
+	defer preferred.Close()
+
+	// the standby node sees the free lock and waits
+	c.Assert(standby.Start(context.Background()), IsNil)
+	clock.BlockUntil(1)
+	c.Assert(preferred.Start(context.Background()), IsNil)
+
+	c.Check(waitOwner(cfg.Locker, "standby", `{"id":"preferred"}`), Equals, `{"id":"preferred"}`)
+	c.Check(preferred.IsLeader(), Equals, true)
+	c.Check(standby.IsLeader(), Equals, false)
+}
+
+// stopLocker stops the loop while Acquire takes the lock
+type stopLocker struct {
+	*Locker
+	stop     context.CancelFunc
+	acquired chan struct{}
+}
+
+func (l *stopLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ok, err := l.Locker.Acquire(context.Background(), key, owner, ttl)
+	l.stop()
+	close(l.acquired)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+	return ok, err
+}
+
+func (s *LeaderTestSuite) Test_Stop_While_Acquire(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	defer cfg.Aero.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	locker := &stopLocker{Locker: cfg.Locker.(*Locker), stop: cancel, acquired: make(chan struct{})}
+	cfg.Locker = locker
+
+	elected := int32(0)
+	cfg.OnElected = func(key string) { atomic.AddInt32(&elected, 1) }
+
+	loads := int32(0)
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("stop", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(ctx), IsNil)
+
+	// the lock which is taken after Stop is released, the loader is not called
+	<-locker.acquired
+	c.Assert(et.Stop(context.Background()), IsNil)
+
+	owner, _ := locker.Owner(context.Background(), "stop")
+	c.Check(owner, Equals, "")
+	c.Check(et.IsLeader(), Equals, false)
+	c.Check(atomic.LoadInt32(&loads), Equals, int32(0))
+	c.Check(atomic.LoadInt32(&elected), Equals, int32(0))
+	c.Check(store.Puts(), Equals, 0)
+}
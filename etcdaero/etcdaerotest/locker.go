@@ -0,0 +1,37 @@
+package etcdaerotest
+
+import (
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+)
+
+// Locker is the in-memory etcdaero.Locker, locks expire by Clock or by Expire.
+// Lockers made from one Table stand for several nodes.
+type Locker struct {
+	*etcdaero.LocalLocker
+	Table *etcdaero.LocalLockTable
+}
+
+// NewTable makes the lock table on clock.
+func NewTable(clock etcdaero.Clock) *etcdaero.LocalLockTable {
+	table := etcdaero.NewLocalLockTable()
+	if clock != nil {
+		table.Clock = clock
+	}
+	return table
+}
+
+// NewLocker makes the locker on table, nil means a new table.
+func NewLocker(table *etcdaero.LocalLockTable) *Locker {
+	if table == nil {
+		table = NewTable(nil)
+	}
+	return &Locker{
+		LocalLocker: etcdaero.NewLocalLocker(table),
+		Table:       table,
+	}
+}
+
+// Expire drops the lock of key as if its ttl is over.
+func (l *Locker) Expire(key string) {
+	l.Table.Expire(key)
+}
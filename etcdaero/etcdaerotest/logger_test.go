@@ -0,0 +1,80 @@
+package etcdaerotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type LoggerTestSuite struct{}
+
+var _ = Suite(&LoggerTestSuite{})
+
+// logBuffer is io.Writer for slog handler in goroutines
+type logBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+// find returns the first record with msg
+func (b *logBuffer) find(msg string) map[string]interface{} {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, line := range strings.Split(b.buf.String(), "\n") {
+		rec := map[string]interface{}{}
+		if json.Unmarshal([]byte(line), &rec) == nil && rec["msg"] == msg {
+			return rec
+		}
+	}
+	return nil
+}
+
+func (s *LoggerTestSuite) Test_Fields(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	out := &logBuffer{}
+
+	cfg := writeConfig(clock, store)
+	cfg.Logger = slog.New(slog.NewJSONHandler(out, nil))
+	defer cfg.Aero.Close()
+
+	reader := &readerTest{}
+	cfg.Aero.StartReader("logged", reader)
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("logged", cfg, f)
+	c.Assert(err, IsNil)
+	c.Assert(et.Start(context.Background()), IsNil)
+	reader.waitFor(c, `{"ok":true}`)
+	clock.BlockUntil(1)
+	c.Assert(et.Close(), IsNil)
+
+	rec := out.find("data is refreshed")
+	c.Assert(rec, NotNil)
+	c.Check(rec["level"], Equals, "INFO")
+	c.Check(rec["key"], Equals, "logged")
+	c.Check(rec["node"], Not(Equals), "")
+	c.Check(rec["version"], Not(Equals), "")
+	c.Check(rec["size"], Equals, float64(11))
+
+	c.Check(out.find("lock is acquired"), NotNil)
+	c.Check(out.find("lock is released"), NotNil)
+}
@@ -0,0 +1,89 @@
+package etcdaerotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type ManagerTestSuite struct{}
+
+var _ = Suite(&ManagerTestSuite{})
+
+func (s *ManagerTestSuite) Test_Manager(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	locker := NewLocker(NewTable(clock))
+
+	m, err := etcdaero.NewManager(&etcdaero.Config{
+		Store:  store,
+		Locker: locker,
+		Clock:  clock,
+	})
+	c.Assert(err, IsNil)
+	defer m.Close()
+
+	fast, slow := 0, 0
+	readerFast, readerSlow := &readerTest{}, &readerTest{}
+
+	_, err = m.Register(etcdaero.Dataset{
+		Name:    "fast",
+		Refresh: time.Minute,
+		Reader:  readerFast,
+		Load: func(params []interface{}) (map[string]interface{}, error) {
+			fast++
+			return map[string]interface{}{"fast": fast}, nil
+		},
+	})
+	c.Assert(err, IsNil)
+
+	_, err = m.Register(etcdaero.Dataset{Name: "fast"})
+	c.Check(err, Equals, etcdaero.ErrDatasetNoLoad)
+
+	_, err = m.Register(etcdaero.Dataset{Name: "fast", Load: func([]interface{}) (map[string]interface{}, error) {
+		return nil, nil
+	}})
+	c.Check(err, Equals, etcdaero.ErrDatasetExists)
+
+	c.Assert(m.Start(context.Background()), IsNil)
+
+	// registered after start
+	_, err = m.Register(etcdaero.Dataset{
+		Name:     "slow",
+		LockKey:  "locks/slow",
+		CacheKey: &etcdaero.StoreKey{Set: "sets", Pk: "slow"},
+		Refresh:  time.Hour,
+		Reader:   readerSlow,
+		Params:   []interface{}{"pooh"},
+		Load: func(params []interface{}) (map[string]interface{}, error) {
+			slow++
+			return map[string]interface{}{"slow": slow, "param": params[0]}, nil
+		},
+	})
+	c.Assert(err, IsNil)
+
+	readerFast.waitFor(c, `{"fast":1}`)
+	readerSlow.waitFor(c, `{"param":"pooh","slow":1}`)
+
+	owner, _ := locker.Owner(context.Background(), "locks/slow")
+	c.Check(owner, Not(Equals), "")
+	c.Check(found(store.LoadEntry(&etcdaero.StoreKey{Set: "sets", Pk: "slow"}, etcdaero.EmptyEtcdAeroEntry())), Equals, true)
+
+	// only fast one is refreshed
+	clock.BlockUntil(2)
+	clock.Advance(time.Minute)
+	readerFast.waitFor(c, `{"fast":2}`)
+	c.Check(slow, Equals, 1)
+
+	res, ok := m.Get("slow")
+	c.Check(ok, Equals, true)
+	c.Check(res, Equals, `{"param":"pooh","slow":1}`)
+
+	c.Assert(m.Stop(context.Background()), IsNil)
+	owner, _ = locker.Owner(context.Background(), "fast")
+	c.Check(owner, Equals, "")
+}
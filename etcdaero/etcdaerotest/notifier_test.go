@@ -0,0 +1,229 @@
+package etcdaerotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type NotifierTestSuite struct{}
+
+var _ = Suite(&NotifierTestSuite{})
+
+func (s *NotifierTestSuite) Test_Other_Node(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	table := NewTable(clock)
+
+	// the leader node
+	leader := etcdaero.NewAeroChecker(store)
+	defer leader.Close()
+
+	// the other node reads the same store, it has no ReLoad from the leader
+	follower := etcdaero.NewAeroChecker(store)
+	defer follower.Close()
+	follower.Watch(NewLocker(table))
+
+	reader := &readerTest{}
+	follower.StartReader("watch", reader)
+
+	cfg := &etcdaero.Config{
+		Locker: NewLocker(table),
+		Clock:  clock,
+		Aero:   leader,
+	}
+
+	count := 0
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		count++
+		return map[string]interface{}{"count": count}, nil
+	}
+
+	et, err := etcdaero.New("watch", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	// the poll is 10 sec, so data is from watch
+	reader.waitFor(c, `{"count":1}`)
+
+	clock.BlockUntil(1)
+	clock.Advance(17 * 61 * time.Second)
+	reader.waitFor(c, `{"count":2}`)
+}
+
+func (s *NotifierTestSuite) Test_Config_Notifier(c *C) {
+	//c.Skip("Not now")
+
+	table := NewTable(nil)
+	notifier := NewLocker(table)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := notifier.Watch(ctx, "etcdaero/version/key/key")
+
+	cfg := &etcdaero.Config{
+		Locker:   NewLocker(NewTable(nil)),
+		Store:    NewStore(nil),
+		Notifier: notifier,
+	}
+	cfg.Aero = etcdaero.NewAeroChecker(cfg.Store)
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+
+	et, err := etcdaero.New("key", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	select {
+	case version := <-ch:
+		c.Check(version, Not(Equals), "")
+	case <-time.After(time.Second):
+		c.Fatal("version is not published")
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+// breakNotifier is Notifier whose watch is closed by Break like etcd client closes it
+// when etcd loses its leader
+type breakNotifier struct {
+	etcdaero.Notifier
+
+	sync.Mutex
+	watches int
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (n *breakNotifier) Watch(ctx context.Context, key string) <-chan string {
+	ctx, cancel := context.WithCancel(ctx)
+	ch := n.Notifier.Watch(ctx, key)
+
+	n.Lock()
+	n.watches++
+	n.ctx, n.cancel = ctx, cancel
+	n.Unlock()
+
+	return ch
+}
+
+// Break closes the current watch, ctx of the caller is not done
+func (n *breakNotifier) Break() {
+	n.Lock()
+	n.cancel()
+	n.Unlock()
+}
+
+func (n *breakNotifier) waitWatches(c *C, count int) {
+	for i := 0; i < 3000; i++ {
+		n.Lock()
+		watches := n.watches
+		n.Unlock()
+		if watches >= count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Fatalf("watch is not opened %d times", count)
+}
+
+func (s *NotifierTestSuite) Test_Watch_Closed(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	notifier := &breakNotifier{Notifier: NewLocker(nil)}
+
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("watch", reader)
+	aero.Watch(notifier)
+
+	key := &etcdaero.StoreKey{Set: "watch", Pk: "watch"}
+	put := func(body string) {
+		store.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte(body), Hash: body}, time.Minute)
+		c.Assert(notifier.Publish(context.Background(), "etcdaero/version/watch/watch", body), IsNil)
+	}
+
+	// the poll is 10 sec, so data is from watch
+	notifier.waitWatches(c, 1)
+	put("v1")
+	reader.waitFor(c, "v1")
+
+	// the watch is opened again after it is closed
+	notifier.Break()
+	notifier.waitWatches(c, 2)
+	put("v2")
+	reader.waitFor(c, "v2")
+
+	notifier.Break()
+	notifier.waitWatches(c, 3)
+	put("v3")
+	reader.waitFor(c, "v3")
+}
+
+func (s *NotifierTestSuite) Test_Unwatch(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	aero := etcdaero.NewAeroChecker(store)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("watch", reader)
+
+	first, second := NewLocker(nil), NewLocker(nil)
+	aero.Watch(first)
+	aero.Watch(second)
+
+	key := &etcdaero.StoreKey{Set: "watch", Pk: "watch"}
+	put := func(n etcdaero.Notifier, body string) {
+		store.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte(body), Hash: body}, time.Minute)
+		c.Assert(n.Publish(context.Background(), "etcdaero/version/watch/watch", body), IsNil)
+	}
+
+	put(first, "v1")
+	reader.waitFor(c, "v1")
+
+	// the next notifier is watched when the first one is removed
+	aero.Unwatch(first)
+	put(second, "v2")
+	reader.waitFor(c, "v2")
+}
+
+func (s *NotifierTestSuite) Test_Close_Unwatch(c *C) {
+	//c.Skip("Not now")
+
+	aero := etcdaero.NewAeroChecker(NewStore(nil))
+	defer aero.Close()
+	aero.StartReader("key")
+
+	notifier := &breakNotifier{Notifier: NewLocker(nil)}
+	cfg := &etcdaero.Config{Locker: NewLocker(nil), Notifier: notifier, Aero: aero}
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+
+	et, err := etcdaero.New("key", cfg, f)
+	c.Assert(err, IsNil)
+	notifier.waitWatches(c, 1)
+
+	// the shared AeroChecker stops watching the notifier of closed EtcdAero
+	c.Assert(et.Close(), IsNil)
+
+	notifier.Lock()
+	defer notifier.Unlock()
+	c.Check(notifier.ctx.Err(), Equals, context.Canceled)
+}
@@ -0,0 +1,192 @@
+package etcdaerotest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type StatusTestSuite struct{}
+
+var _ = Suite(&StatusTestSuite{})
+
+func (s *StatusTestSuite) Test_Status(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	cfg.OnError = func(err error) {}
+	cfg.Aero.SetClock(clock)
+	defer cfg.Aero.Close()
+
+	reader := &readerTest{}
+	cfg.Aero.StartReader("status", reader)
+
+	fail := false
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		if fail {
+			return nil, ErrWrite
+		}
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("status", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+
+	st := et.Status(context.Background())
+	c.Check(st.Key, Equals, "status")
+	c.Check(st.Leader, Equals, false)
+	c.Check(st.LockHolder, Equals, "")
+	c.Check(st.Loaded.IsZero(), Equals, true)
+
+	c.Assert(et.Start(context.Background()), IsNil)
+	reader.waitFor(c, `{"ok":true}`)
+	clock.BlockUntil(1)
+
+	st = et.Status(context.Background())
+	c.Check(st.Leader, Equals, true)
+	c.Check(st.LockHolder, Not(Equals), "")
+	c.Check(st.Refreshed, Equals, clock.Now())
+	c.Check(st.Version, Not(Equals), "")
+	c.Check(st.Loaded, Equals, clock.Now())
+	c.Check(st.DataTime, Equals, clock.Now())
+	c.Check(st.AgeSeconds, Equals, 0.0)
+	c.Check(st.LastError, Equals, "")
+
+	// the failed refresh drops the lock, the data is kept
+	fail = true
+	clock.Advance(17 * 61 * time.Second)
+	clock.BlockUntil(1)
+
+	st = et.Status(context.Background())
+	c.Check(st.Leader, Equals, false)
+	c.Check(st.LockHolder, Equals, "")
+	c.Check(st.Version, Not(Equals), "")
+	c.Check(st.AgeSeconds, Equals, (17 * 61 * time.Second).Seconds())
+	c.Check(st.LastError, Matches, "load status: loader failed: .*")
+}
+
+func (s *StatusTestSuite) Test_Health(c *C) {
+	//c.Skip("Not now")
+
+	aero := etcdaero.NewAeroChecker(NewStore(nil))
+	defer aero.Close()
+	aero.SetOnError(func(err error) {})
+
+	aero.StartReader("fresh")
+	aero.StartReader("old")
+
+	srv := httptest.NewServer(&etcdaero.HealthHandler{
+		Status: aero.Status,
+		MaxAge: 10 * time.Minute,
+	})
+	defer srv.Close()
+
+	get := func(path string) (int, map[string]interface{}) {
+		resp, err := http.Get(srv.URL + path)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+
+		out := map[string]interface{}{}
+		c.Assert(json.NewDecoder(resp.Body).Decode(&out), IsNil)
+		return resp.StatusCode, out
+	}
+
+	code, _ := get("/health/live")
+	c.Check(code, Equals, http.StatusOK)
+
+	code, out := get("/health/ready")
+	c.Check(code, Equals, http.StatusServiceUnavailable)
+	c.Check(out["not_ready"], DeepEquals, []interface{}{"fresh", "old"})
+
+	aero.Put(&etcdaero.StoreKey{Set: "fresh", Pk: "fresh"}, &etcdaero.EtcdAeroEntry{Body: []byte(`{}`), Time: time.Now()}, time.Minute)
+	aero.Put(&etcdaero.StoreKey{Set: "old", Pk: "old"}, &etcdaero.EtcdAeroEntry{Body: []byte(`{}`), Time: time.Now().Add(-time.Hour)}, time.Minute)
+	aero.ReLoad()
+
+	for i := 0; i < 1000; i++ {
+		if code, out = get("/health/ready"); len(out["not_ready"].([]interface{})) == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Check(code, Equals, http.StatusServiceUnavailable)
+	c.Check(out["not_ready"], DeepEquals, []interface{}{"old"})
+
+	code, out = get("/health")
+	c.Check(code, Equals, http.StatusOK)
+	datasets := out["datasets"].([]interface{})
+	c.Assert(datasets, HasLen, 2)
+	c.Check(datasets[1].(map[string]interface{})["age_seconds"].(float64) > 3599, Equals, true)
+
+	// readiness of some datasets only
+	h := &etcdaero.HealthHandler{
+		Status: aero.Status,
+		MaxAge: 10 * time.Minute,
+		Keys:   []string{"fresh"},
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	c.Check(rec.Code, Equals, http.StatusOK)
+}
+
+func (s *StatusTestSuite) Test_Health_No_Status(c *C) {
+	//c.Skip("Not now")
+
+	h := &etcdaero.HealthHandler{}
+	for _, path := range []string{"/health", "/health/live", "/health/ready"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		c.Check(rec.Code, Equals, http.StatusOK)
+	}
+
+	// required datasets are not ready without states
+	h.Keys = []string{"data"}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/health/ready", nil))
+	c.Check(rec.Code, Equals, http.StatusServiceUnavailable)
+}
+
+func (s *StatusTestSuite) Test_Manager(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	m, err := etcdaero.NewManager(&etcdaero.Config{
+		Locker: NewLocker(NewTable(clock)),
+		Store:  NewStore(clock),
+		Clock:  clock,
+	})
+	c.Assert(err, IsNil)
+	defer m.Close()
+
+	load := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+	_, err = m.Register(etcdaero.Dataset{Name: "b", LockKey: "lock-b", Load: load})
+	c.Assert(err, IsNil)
+	_, err = m.Register(etcdaero.Dataset{Name: "a", Load: load})
+	c.Assert(err, IsNil)
+	c.Assert(m.Start(context.Background()), IsNil)
+
+	for i := 0; i < 1000; i++ {
+		if len((&etcdaero.HealthHandler{Status: m.Status}).NotReady(context.Background())) == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	list := m.Status(context.Background())
+	c.Assert(list, HasLen, 2)
+	c.Check(list[0].Key, Equals, "a")
+	c.Check(list[1].Key, Equals, "b")
+	c.Check(list[1].Leader, Equals, true)
+	c.Check(list[1].LockHolder, Not(Equals), "")
+	c.Check(list[1].Loaded, Equals, clock.Now())
+	c.Check(list[1].AgeSeconds, Equals, 0.0)
+}
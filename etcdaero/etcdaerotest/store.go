@@ -0,0 +1,166 @@
+package etcdaerotest
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+)
+
+// binToken is the bin of fencing token
+const binToken = "token"
+
+// ErrWrite and ErrRead are returned by failed writes and reads of Store
+var (
+	ErrWrite = errors.New("store write error")
+	ErrRead  = errors.New("store read error")
+)
+
+// Store is the in-memory etcdaero.Store, entries expire by Clock.
+type Store struct {
+	sync.RWMutex
+	Clock   etcdaero.Clock
+	entries map[string]entry
+	puts    int
+	fails   int
+	rfails  int
+}
+
+type entry struct {
+	bins    map[string]interface{}
+	expires time.Time
+}
+
+// NewStore makes Store with clock, nil means a new fake Clock.
+func NewStore(clock etcdaero.Clock) *Store {
+	if clock == nil {
+		clock = NewClock()
+	}
+	return &Store{
+		Clock:   clock,
+		entries: map[string]entry{},
+	}
+}
+
+func _key(key *etcdaero.StoreKey) string {
+	return key.Set + "." + key.Pk
+}
+
+// PutEntry stores data at once, not in goroutine.
+func (s *Store) PutEntry(key *etcdaero.StoreKey, data etcdaero.IEntryData, ttl time.Duration) {
+	s.WriteEntry(key, data, ttl)
+}
+
+func (s *Store) WriteEntry(key *etcdaero.StoreKey, data etcdaero.IEntryData, ttl time.Duration) error {
+	return s._write(key, data, ttl, 0)
+}
+
+// WriteFenced stores data with token in "token" bin,
+// it fails with etcdaero.ErrStaleToken if the stored token is greater.
+func (s *Store) WriteFenced(key *etcdaero.StoreKey, data etcdaero.IEntryData, ttl time.Duration, token uint64) error {
+	return s._write(key, data, ttl, token)
+}
+
+// _write keeps the stored token on the plain write like aerospike update does
+func (s *Store) _write(key *etcdaero.StoreKey, data etcdaero.IEntryData, ttl time.Duration, token uint64) error {
+	bins := map[string]interface{}{}
+	for k, v := range data.Export() {
+		bins[k] = v
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.fails > 0 {
+		s.fails--
+		return ErrWrite
+	}
+
+	stored, _ := s.entries[_key(key)].bins[binToken].(uint64)
+	switch {
+	case token == 0 && stored > 0:
+		bins[binToken] = stored
+	case token > 0 && stored > token:
+		return etcdaero.ErrStaleToken
+	case token > 0:
+		bins[binToken] = token
+	}
+
+	s.entries[_key(key)] = entry{
+		bins:    bins,
+		expires: s.Clock.Now().Add(ttl),
+	}
+	s.puts++
+
+	return nil
+}
+
+// Fail makes the next n writes fail with ErrWrite.
+func (s *Store) Fail(n int) {
+	s.Lock()
+	s.fails = n
+	s.Unlock()
+}
+
+// FailLoads makes the next n reads fail with ErrRead.
+func (s *Store) FailLoads(n int) {
+	s.Lock()
+	s.rfails = n
+	s.Unlock()
+}
+
+func (s *Store) LoadEntry(key *etcdaero.StoreKey, buf etcdaero.IEntryData) (bool, error) {
+	s.Lock()
+	e, ok := s.entries[_key(key)]
+	if s.rfails > 0 {
+		s.rfails--
+		s.Unlock()
+		return false, ErrRead
+	}
+	s.Unlock()
+
+	if !ok || !s.Clock.Now().Before(e.expires) {
+		return false, nil
+	}
+
+	if err := buf.Import(e.bins); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Store) DeleteEntry(key *etcdaero.StoreKey) error {
+	s.Lock()
+	delete(s.entries, _key(key))
+	s.Unlock()
+
+	return nil
+}
+
+func (s *Store) Close() {}
+
+// Puts returns the count of successful writes.
+func (s *Store) Puts() int {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.puts
+}
+
+// Token returns the fencing token of the stored entry, 0 if it is not fenced.
+func (s *Store) Token(key *etcdaero.StoreKey) uint64 {
+	s.RLock()
+	defer s.RUnlock()
+
+	token, _ := s.entries[_key(key)].bins[binToken].(uint64)
+	return token
+}
+
+// Len returns the count of stored entries, expired ones too.
+func (s *Store) Len() int {
+	s.RLock()
+	defer s.RUnlock()
+
+	return len(s.entries)
+}
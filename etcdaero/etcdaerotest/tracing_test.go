@@ -0,0 +1,168 @@
+package etcdaerotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	. "gopkg.in/check.v1"
+)
+
+type TracingTestSuite struct{}
+
+var _ = Suite(&TracingTestSuite{})
+
+// waitSpan returns the ended span with name
+func waitSpan(c *C, rec *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for i := 0; i < 1000; i++ {
+		for _, span := range rec.Ended() {
+			if span.Name() == name {
+				return span
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Fatalf("no span %s", name)
+	return nil
+}
+
+func (s *TracingTestSuite) Test_Spans(c *C) {
+	//c.Skip("Not now")
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	cfg.TracerProvider = tp
+	cfg.Aero.SetTracerProvider(tp)
+	defer cfg.Aero.Close()
+
+	reader := &readerTest{}
+	cfg.Aero.StartReader("traced", reader)
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	et, err := etcdaero.New("traced", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+	reader.waitFor(c, `{"ok":true}`)
+
+	refresh := waitSpan(c, rec, "etcdaero.refresh")
+	load := waitSpan(c, rec, "etcdaero.load")
+	encode := waitSpan(c, rec, "etcdaero.encode")
+	put := waitSpan(c, rec, "etcdaero.put")
+	write := waitSpan(c, rec, "etcdaero.store.write")
+
+	c.Check(load.Parent().SpanID(), Equals, refresh.SpanContext().SpanID())
+	c.Check(encode.Parent().SpanID(), Equals, load.SpanContext().SpanID())
+	c.Check(put.Parent().SpanID(), Equals, refresh.SpanContext().SpanID())
+	c.Check(write.Parent().SpanID(), Equals, put.SpanContext().SpanID())
+
+	attrs := map[string]interface{}{}
+	for _, kv := range put.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	c.Check(attrs["etcdaero.key"], Equals, "traced")
+	c.Check(attrs["etcdaero.size"], Equals, int64(11))
+
+	// the reload of reader is linked to the write of leader
+	reload := waitSpan(c, rec, "etcdaero.reload")
+	renew := waitSpan(c, rec, "etcdaero.renew")
+	read := waitSpan(c, rec, "etcdaero.store.read")
+
+	c.Check(renew.Parent().SpanID(), Equals, reload.SpanContext().SpanID())
+	c.Check(read.Parent().SpanID(), Equals, reload.SpanContext().SpanID())
+	c.Assert(reload.Links(), HasLen, 1)
+	c.Check(reload.Links()[0].SpanContext.TraceID(), Equals, put.SpanContext().TraceID())
+	c.Check(reload.Links()[0].SpanContext.SpanID(), Equals, put.SpanContext().SpanID())
+}
+
+func (s *TracingTestSuite) Test_Manager(c *C) {
+	//c.Skip("Not now")
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	clock := NewClock()
+	m, err := etcdaero.NewManager(&etcdaero.Config{
+		Store:          NewStore(clock),
+		Locker:         NewLocker(NewTable(clock)),
+		Clock:          clock,
+		TracerProvider: tp,
+	})
+	c.Assert(err, IsNil)
+	defer m.Close()
+
+	reader := &readerTest{}
+	_, err = m.Register(etcdaero.Dataset{
+		Name:   "traced",
+		Reader: reader,
+		Load: func(params []interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"ok": true}, nil
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(m.Start(context.Background()), IsNil)
+	reader.waitFor(c, `{"ok":true}`)
+
+	put := waitSpan(c, rec, "etcdaero.put")
+
+	// the reload of Manager's reader is traced too, the read is inside of it
+	var reload, read sdktrace.ReadOnlySpan
+	for i := 0; i < 1000 && reload == nil; i++ {
+		for _, span := range rec.Ended() {
+			if span.Name() == "etcdaero.reload" && len(span.Links()) > 0 {
+				reload = span
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(reload, NotNil)
+	c.Check(reload.Links()[0].SpanContext.SpanID(), Equals, put.SpanContext().SpanID())
+
+	for _, span := range rec.Ended() {
+		if span.Name() == "etcdaero.store.read" && span.Parent().SpanID() == reload.SpanContext().SpanID() {
+			read = span
+		}
+	}
+	c.Assert(read, NotNil)
+	c.Check(read.StartTime().Before(reload.StartTime()), Equals, false)
+	c.Check(read.EndTime().After(reload.EndTime()), Equals, false)
+}
+
+func (s *TracingTestSuite) Test_Loader_Error(c *C) {
+	//c.Skip("Not now")
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	clock := NewClock()
+	cfg := writeConfig(clock, NewStore(clock))
+	cfg.TracerProvider = tp
+	cfg.OnError = func(err error) {}
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return nil, ErrWrite
+	}
+
+	et, err := etcdaero.New("failed", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	load := waitSpan(c, rec, "etcdaero.load")
+	c.Check(load.Status().Code.String(), Equals, "Error")
+	c.Assert(load.Events(), HasLen, 1)
+	c.Check(load.Events()[0].Name, Equals, "exception")
+
+	refresh := waitSpan(c, rec, "etcdaero.refresh")
+	c.Check(refresh.Status().Code.String(), Equals, "Error")
+}
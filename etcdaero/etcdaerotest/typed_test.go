@@ -0,0 +1,87 @@
+package etcdaerotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type TypedTestSuite struct{}
+
+var _ = Suite(&TypedTestSuite{})
+
+type catalogTest struct {
+	Items map[string]int64
+	Name  string
+}
+
+func waitTyped[T any](c *C, ds *etcdaero.TypedDataset[T], ok func(T) bool) T {
+	for i := 0; i < 1000; i++ {
+		if data, found := ds.Get(); found && ok(data) {
+			return data
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Fatal("typed dataset is not loaded")
+	panic("unreachable")
+}
+
+func (s *TypedTestSuite) Test_NewDataset(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	aero := etcdaero.NewAeroChecker(NewStore(clock))
+	defer aero.Close()
+
+	cfg := &etcdaero.Config{
+		Locker: NewLocker(NewTable(clock)),
+		Clock:  clock,
+		Aero:   aero,
+	}
+
+	ds, err := etcdaero.NewDataset("typed", cfg, func(ctx context.Context) (catalogTest, error) {
+		return catalogTest{Name: "pooh", Items: map[string]int64{"honey": 1 << 60}}, nil
+	})
+	c.Assert(err, IsNil)
+
+	_, found := ds.Get()
+	c.Check(found, Equals, false)
+
+	c.Assert(ds.Start(context.Background()), IsNil)
+	defer ds.Close()
+
+	data := waitTyped(c, ds, func(catalogTest) bool { return true })
+	c.Check(data.Name, Equals, "pooh")
+	c.Check(data.Items["honey"], Equals, int64(1<<60))
+}
+
+func (s *TypedTestSuite) Test_RegisterDataset(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	m, err := etcdaero.NewManager(&etcdaero.Config{
+		Store:  NewStore(clock),
+		Locker: NewLocker(NewTable(clock)),
+		Clock:  clock,
+	})
+	c.Assert(err, IsNil)
+	defer m.Close()
+
+	count := 0
+	ds, err := etcdaero.RegisterDataset(m, etcdaero.Dataset{Name: "list", Refresh: time.Minute}, func(ctx context.Context) ([]int, error) {
+		count++
+		return []int{count, count * 10}, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(m.Start(context.Background()), IsNil)
+
+	data := waitTyped(c, ds, func(d []int) bool { return len(d) == 2 })
+	c.Check(data, DeepEquals, []int{1, 10})
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	data = waitTyped(c, ds, func(d []int) bool { return d[0] == 2 })
+	c.Check(data, DeepEquals, []int{2, 20})
+}
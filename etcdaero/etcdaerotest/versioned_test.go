@@ -0,0 +1,154 @@
+package etcdaerotest
+
+import (
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type VersionedTestSuite struct{}
+
+var _ = Suite(&VersionedTestSuite{})
+
+func (s *VersionedTestSuite) Test_Pointer(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	vs := etcdaero.NewVersionedStore(store)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "pk"}
+
+	vs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("v1")}, time.Minute)
+	v1, ok, err := vs.Version(key)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+
+	clock.Advance(30 * time.Second)
+	vs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("v2")}, time.Minute)
+	v2, _, _ := vs.Version(key)
+	c.Check(v2, Not(Equals), v1)
+
+	// pointer + 2 generations
+	c.Check(store.Len(), Equals, 3)
+
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(vs.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "v2")
+
+	// reader which has got the old pointer still reads the whole old generation
+	c.Assert(found(vs.LoadVersion(key, v1, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "v1")
+
+	// until it expires
+	clock.Advance(30 * time.Second)
+	c.Check(found(vs.LoadVersion(key, v1, buf)), Equals, false)
+	c.Check(found(vs.LoadEntry(key, buf)), Equals, true)
+
+	c.Assert(vs.DeleteEntry(key), IsNil)
+	c.Check(found(vs.LoadEntry(key, buf)), Equals, false)
+}
+
+func (s *VersionedTestSuite) Test_Old_Entry(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	vs := etcdaero.NewVersionedStore(store)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "pk"}
+
+	store.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("old")}, time.Minute)
+
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(vs.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "old")
+}
+
+func (s *VersionedTestSuite) Test_AeroChecker_Skip(c *C) {
+	//c.Skip("Not now")
+
+	vs := etcdaero.NewVersionedStore(NewStore(nil))
+	aero := etcdaero.NewAeroChecker(vs)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("key", reader)
+
+	key := &etcdaero.StoreKey{Set: "key", Pk: "key"}
+	vs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("v1")}, time.Minute)
+	aero.ReLoad()
+	reader.waitFor(c, "v1")
+
+	// not changed
+	aero.ReLoad()
+	aero.ReLoad()
+	vs.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("v2")}, time.Minute)
+	aero.ReLoad()
+	reader.waitFor(c, "v2")
+
+	c.Check(aero.SkippedReloads(), Equals, int64(2))
+
+	reader.Lock()
+	defer reader.Unlock()
+	c.Check(reader.list, DeepEquals, []string{"v1", "v2"})
+}
+
+func (s *VersionedTestSuite) Test_Same_Hash(c *C) {
+	//c.Skip("Not now")
+
+	store := NewStore(nil)
+	vs := etcdaero.NewVersionedStore(store)
+	aero := etcdaero.NewAeroChecker(vs)
+	defer aero.Close()
+
+	reader := &readerTest{}
+	aero.StartReader("key", reader)
+
+	key := &etcdaero.StoreKey{Set: "key", Pk: "key"}
+	c.Assert(aero.WriteFenced(key, &etcdaero.EtcdAeroEntry{Body: []byte("v1"), Hash: "h1"}, time.Minute, 1), IsNil)
+	v1, _, _ := vs.Version(key)
+	aero.ReLoad()
+	reader.waitFor(c, "v1")
+
+	// the same data keeps the version, it is not reloaded
+	for i := int64(1); i <= 2; i++ {
+		c.Assert(aero.WriteFenced(key, &etcdaero.EtcdAeroEntry{Body: []byte("v1"), Hash: "h1"}, time.Minute, 1), IsNil)
+		aero.ReLoad()
+		waitSkipped(c, aero, i)
+	}
+
+	version, _, _ := vs.Version(key)
+	c.Check(version, Equals, v1)
+	// pointer + 1 generation
+	c.Check(store.Len(), Equals, 2)
+
+	c.Assert(aero.WriteFenced(key, &etcdaero.EtcdAeroEntry{Body: []byte("v2"), Hash: "h2"}, time.Minute, 1), IsNil)
+	aero.ReLoad()
+	reader.waitFor(c, "v2")
+
+	reader.Lock()
+	defer reader.Unlock()
+	c.Check(reader.list, DeepEquals, []string{"v1", "v2"})
+}
+
+func (s *VersionedTestSuite) Test_Chunked(c *C) {
+	//c.Skip("Not now")
+
+	store, err := etcdaero.NewStore(&etcdaero.Config{
+		Store:     NewStore(nil),
+		ChunkSize: 4,
+		Versioned: true,
+	})
+	c.Assert(err, IsNil)
+	key := &etcdaero.StoreKey{Set: "set", Pk: "pk"}
+
+	store.PutEntry(key, &etcdaero.EtcdAeroEntry{Body: []byte("long body")}, time.Minute)
+
+	version, ok, err := store.(etcdaero.Versioner).Version(key)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Check(version, Not(Equals), "")
+
+	buf := etcdaero.EmptyEtcdAeroEntry()
+	c.Assert(found(store.LoadEntry(key, buf)), Equals, true)
+	c.Check(string(buf.Body), Equals, "long body")
+}
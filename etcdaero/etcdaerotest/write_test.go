@@ -0,0 +1,101 @@
+package etcdaerotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+	. "gopkg.in/check.v1"
+)
+
+type WriteTestSuite struct{}
+
+var _ = Suite(&WriteTestSuite{})
+
+func writeConfig(clock *Clock, store *Store) *etcdaero.Config {
+	return &etcdaero.Config{
+		Locker: NewLocker(NewTable(clock)),
+		Clock:  clock,
+		Aero:   etcdaero.NewAeroChecker(store),
+	}
+}
+
+// waitOwner waits a while for owner of key, it returns the last one
+func waitOwner(locker etcdaero.Locker, key, owner string) string {
+	got := ""
+	for i := 0; i < 1000; i++ {
+		got, _ = locker.Owner(context.Background(), key)
+		if got == owner {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return got
+}
+
+func (s *WriteTestSuite) Test_Retry(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	defer cfg.Aero.Close()
+
+	reader := &readerTest{}
+	cfg.Aero.StartReader("retry", reader)
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	store.Fail(2)
+
+	et, err := etcdaero.New("retry", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	// 100 ms, 200 ms beside the keepalive
+	clock.BlockUntil(2)
+	clock.Advance(100 * time.Millisecond)
+	clock.BlockUntil(2)
+	c.Check(store.Puts(), Equals, 0)
+	clock.Advance(200 * time.Millisecond)
+
+	reader.waitFor(c, `{"ok":true}`)
+	c.Check(store.Puts(), Equals, 1)
+
+	owner, _ := cfg.Locker.Owner(context.Background(), "retry")
+	c.Check(owner, Not(Equals), "")
+}
+
+func (s *WriteTestSuite) Test_Release(c *C) {
+	//c.Skip("Not now")
+
+	clock := NewClock()
+	store := NewStore(clock)
+	cfg := writeConfig(clock, store)
+	cfg.WriteRetries = 1
+	cfg.WriteBackoff = time.Second
+	defer cfg.Aero.Close()
+
+	f := func(params []interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	store.Fail(100)
+
+	et, err := etcdaero.New("release", cfg, f)
+	c.Assert(err, IsNil)
+	defer et.Close()
+	c.Assert(et.Start(context.Background()), IsNil)
+
+	clock.BlockUntil(2)
+	owner, _ := cfg.Locker.Owner(context.Background(), "release")
+	c.Check(owner, Not(Equals), "")
+
+	// the lock is released after the last retry, another node can try
+	clock.Advance(time.Second)
+	c.Check(waitOwner(cfg.Locker, "release", ""), Equals, "")
+	c.Check(store.Puts(), Equals, 0)
+}
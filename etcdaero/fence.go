@@ -0,0 +1,56 @@
+package etcdaero
+
+import (
+	"errors"
+	"time"
+)
+
+// binToken is the bin of fencing token
+const binToken = "token"
+
+// errFenceConflict is returned when concurrent writes do not let the check pass
+var errFenceConflict = errors.New("fenced write conflict")
+
+// Fencer is Locker which gives the fencing token of the held lock.
+// The token of the next holder is greater, so the store rejects
+// late writes of the stale leader.
+type Fencer interface {
+	// Token returns the token of the lock which is held by this locker, 0 means no token.
+	Token(key string) uint64
+}
+
+// FencedStore is Store with the conditional write.
+type FencedStore interface {
+	// WriteFenced stores data with token, it fails with ErrStaleToken
+	// if the stored token is greater than token.
+	WriteFenced(key *StoreKey, data IEntryData, ttl time.Duration, token uint64) error
+}
+
+// tokenOf is the fencing token of key, 0 if locker is not Fencer
+func tokenOf(locker Locker, key string) uint64 {
+	if f, ok := locker.(Fencer); ok {
+		return f.Token(key)
+	}
+	return 0
+}
+
+// writeFenced writes with token if store supports it, zero token is the plain write
+func writeFenced(store Store, key *StoreKey, data IEntryData, ttl time.Duration, token uint64) error {
+	if fs, ok := store.(FencedStore); ok && token > 0 {
+		return fs.WriteFenced(key, data, ttl, token)
+	}
+	return store.WriteEntry(key, data, ttl)
+}
+
+// ResetToken removes the cache entry with its fencing token.
+// Tokens of different lockers are not comparable, so it is the step of the switch
+// to other Locker, e.g. etcd v2 to v3: without it the writes of the new leader fail
+// with ErrStaleToken until the entry expires. Readers keep their data until the next write.
+func (ea *EtcdAero) ResetToken() error {
+	return ea.Aero.Conn.DeleteEntry(ea.cacheKey)
+}
+
+// toToken converts token bin, missing bin is 0
+func toToken(v interface{}) uint64 {
+	return uint64(toInt(v))
+}
@@ -0,0 +1,91 @@
+package etcdaero
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StatusFunc returns states of datasets: AeroChecker.Status, Manager.Status
+// or the func with EtcdAero.Status.
+type StatusFunc func(ctx context.Context) []Status
+
+// HealthHandler serves states as JSON:
+// <prefix>/live is liveness, <prefix>/ready is readiness, other paths are the full state.
+type HealthHandler struct {
+	// Status is the source of states, nil is no datasets: the handler is live and ready
+	// unless Keys are set
+	Status StatusFunc
+	// MaxAge is the max age of data for readiness, 0 is any age
+	MaxAge time.Duration
+	// Keys are the datasets for readiness, nil is all of them
+	Keys []string
+}
+
+// healthResponse is the JSON of HealthHandler
+type healthResponse struct {
+	Status   string   `json:"status"`
+	NotReady []string `json:"not_ready,omitempty"`
+	Datasets []Status `json:"datasets,omitempty"`
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch {
+	case strings.HasSuffix(path, "/live"):
+		h._write(w, http.StatusOK, healthResponse{Status: "ok"})
+	case strings.HasSuffix(path, "/ready"):
+		notReady := h.NotReady(r.Context())
+		if len(notReady) > 0 {
+			h._write(w, http.StatusServiceUnavailable, healthResponse{Status: "not ready", NotReady: notReady})
+			return
+		}
+		h._write(w, http.StatusOK, healthResponse{Status: "ok"})
+	default:
+		h._write(w, http.StatusOK, healthResponse{Status: "ok", Datasets: h._status(r.Context())})
+	}
+}
+
+// NotReady returns datasets which are not loaded yet or older than MaxAge.
+func (h *HealthHandler) NotReady(ctx context.Context) []string {
+	list := h._status(ctx)
+	byKey := make(map[string]Status, len(list))
+	for _, st := range list {
+		byKey[st.Key] = st
+	}
+
+	keys := h.Keys
+	if keys == nil {
+		for _, st := range list {
+			keys = append(keys, st.Key)
+		}
+	}
+
+	out := []string{}
+	for _, key := range keys {
+		st, ok := byKey[key]
+		// the age is counted by the clock of the reader
+		age := time.Duration(st.AgeSeconds * float64(time.Second))
+		if !ok || st.Loaded.IsZero() || (h.MaxAge > 0 && age > h.MaxAge) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// _status returns states of Status, nil if it is not set
+func (h *HealthHandler) _status(ctx context.Context) []Status {
+	if h.Status == nil {
+		return nil
+	}
+	return h.Status(ctx)
+}
+
+func (h *HealthHandler) _write(w http.ResponseWriter, code int, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
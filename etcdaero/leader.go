@@ -0,0 +1,313 @@
+package etcdaero
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Leadership of EtcdAero is the state machine:
+//
+//	follower --elected--> leader     the lock is acquired
+//	leader --step down--> follower   refresh failed or Stop, the lock is released
+//	leader --lost-------> follower   the lock is not renewed, the in-flight
+//	                                 refresh is canceled and Config.OnLost is called
+//
+// The keepalive renews the lock beside the refresh, so a slow loader does not lose it.
+
+// ttls are TTLs of the running loops, they are copied at Start,
+// so SetTTL does not race with the loops
+type ttls struct {
+	timer, lock, renew, sleep, aero time.Duration
+}
+
+// _ttls copies TTLs, caller holds ea.mu
+func (ea *EtcdAero) _ttls() ttls {
+	return ttls{
+		timer: ea.timerTTL,
+		lock:  ea.etcdLockTTL,
+		renew: ea.renewTTL,
+		sleep: ea.sleepTTL,
+		aero:  ea.AeroTTL,
+	}
+}
+
+// errStepDown is the cause of leader context when the leader gives up the lock itself
+var errStepDown = errors.New("leader steps down")
+
+// _initCaching is the follower state, it tries to take the lock until ctx is done
+func (ea *EtcdAero) _initCaching(ctx context.Context, t ttls, done chan struct{}) {
+	defer close(done)
+
+	if ea.cfg.OnLeaderChanged != nil {
+		watched := make(chan struct{})
+		go ea._watchLeader(ctx, t.renew, watched)
+		defer func() { <-watched }()
+	}
+
+	fails := 0
+	for {
+		ok, err := ea._tryLock(ctx, t)
+		switch {
+		case err != nil:
+			fails++
+		case !ok:
+			fails = 0
+		case ea._lead(ctx, t) != nil:
+			fails++
+		default:
+			fails = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ea.clock.After(ea._pause(t, fails)):
+		}
+	}
+}
+
+// _lead is the leader state, it returns after step down or lost lock.
+// The error is the failed refresh which makes the leader step down.
+func (ea *EtcdAero) _lead(ctx context.Context, t ttls) error {
+	leadCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	kept := make(chan struct{})
+	go ea._keepalive(leadCtx, t, cancel, kept)
+
+	err := ea._refreshLoop(leadCtx, t)
+	cancel(errStepDown)
+	<-kept
+
+	if errors.Is(context.Cause(leadCtx), ErrLockLost) {
+		ea._lost()
+		return nil
+	}
+
+	ea.releaseLock()
+	return err
+}
+
+// _refreshLoop loads and writes data each timerTTL until ctx is done,
+// it returns the error of refresh which is not caused by ctx
+func (ea *EtcdAero) _refreshLoop(ctx context.Context, t ttls) error {
+	for {
+		if err := ea._refresh(ctx, t); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			ea._error(err)
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ea.clock.After(t.timer):
+		}
+	}
+}
+
+// _keepalive renews the lock each renewTTL independently of refresh.
+// The lock is lost if the locker says so or it is not renewed during the lock ttl,
+// then the leader context is canceled with ErrLockLost.
+func (ea *EtcdAero) _keepalive(ctx context.Context, t ttls, lost context.CancelCauseFunc, done chan struct{}) {
+	defer close(done)
+
+	renewed := ea.clock.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ea.clock.After(t.renew):
+		}
+
+		ok, err := ea.renewLock(ctx, t)
+		if ctx.Err() != nil {
+			return
+		}
+		if ok {
+			renewed = ea.clock.Now()
+			continue
+		}
+
+		// the backend failed, the lock is ours until its ttl is over
+		if err != nil && ea.clock.Now().Add(t.renew).Before(renewed.Add(t.lock)) {
+			continue
+		}
+
+		lost(ErrLockLost)
+		return
+	}
+}
+
+// getLock takes the lock with ctx of the loop, the lock which is taken
+// when ctx is done already is released at once and this node does not lead
+func (ea *EtcdAero) getLock(ctx context.Context, t ttls) (bool, error) {
+	ok, err := ea.locker.Acquire(ctx, ea.key, ea.value, t.lock)
+	if err != nil && ctx.Err() == nil {
+		ea._error(newError(OpLock, ea.key, ErrLockFailed, err))
+	}
+	if ok && ctx.Err() != nil {
+		// Ignore any errors
+		ea.locker.Release(context.Background(), ea.key, ea.value)
+		return false, nil
+	}
+	if ok {
+		ea.token = tokenOf(ea.locker, ea.key)
+		ea.log.info("lock is acquired", "ttl", t.lock, "token", ea.token)
+		ea.metrics.Leader(ea.name, true)
+		ea._setLeader(true)
+
+		if ea.cfg.OnElected != nil {
+			ea.cfg.OnElected(ea.key)
+		}
+	}
+	return ok, err
+}
+
+// renewLock prolongs the lock, false without error means it is held by somebody else
+func (ea *EtcdAero) renewLock(ctx context.Context, t ttls) (bool, error) {
+	ok, err := ea.locker.Renew(ctx, ea.key, ea.value, t.lock)
+	if err != nil && ctx.Err() == nil {
+		ea._error(newError(OpRenew, ea.key, ErrLockFailed, err))
+	}
+	return ok, err
+}
+
+func (ea *EtcdAero) releaseLock() {
+	// Ignore any errors
+	ea.locker.Release(context.Background(), ea.key, ea.value)
+	ea.token = 0
+	ea.log.info("lock is released")
+	ea._revoked()
+}
+
+// _lost is the transition to follower without release, the lock is not ours already
+func (ea *EtcdAero) _lost() {
+	ea.token = 0
+	ea._revoked()
+	ea._error(newError(OpRenew, ea.key, ErrLockLost, nil))
+
+	if ea.cfg.OnLost != nil {
+		ea.cfg.OnLost(ea.key)
+	}
+}
+
+// _revoked is the end of leadership of this node
+func (ea *EtcdAero) _revoked() {
+	ea.metrics.Leader(ea.name, false)
+	ea._setLeader(false)
+
+	if ea.cfg.OnRevoked != nil {
+		ea.cfg.OnRevoked(ea.key)
+	}
+}
+
+// IsLeader tells if this node holds the lock now.
+func (ea *EtcdAero) IsLeader() bool {
+	ea.stMu.Lock()
+	defer ea.stMu.Unlock()
+
+	return ea.leader
+}
+
+// Leader returns the node id of the lock holder, "" if the lock is free.
+func (ea *EtcdAero) Leader(ctx context.Context) (string, error) {
+	node, err := ea.LeaderNode(ctx)
+	return node.ID, err
+}
+
+// LeaderNode returns the lock holder with its metadata.
+func (ea *EtcdAero) LeaderNode(ctx context.Context) (Node, error) {
+	owner, err := ea.locker.Owner(ctx, ea.key)
+	if err != nil {
+		return Node{}, err
+	}
+	return ParseNode(owner), nil
+}
+
+// NodeID is the identity of this node.
+func (ea *EtcdAero) NodeID() string {
+	return ea.node.ID
+}
+
+// Observe sends the node id of the leader when it changes, "" is no leader.
+// The current leader is sent first, the channel is closed when ctx is done.
+// The lock key is watched if Locker is Observer, else it is polled.
+func (ea *EtcdAero) Observe(ctx context.Context) <-chan string {
+	ea.mu.Lock()
+	every := ea.renewTTL
+	ea.mu.Unlock()
+
+	return ea._observe(ctx, every)
+}
+
+// _observe is Observe which polls the locker without watch each every
+func (ea *EtcdAero) _observe(ctx context.Context, every time.Duration) <-chan string {
+	var in <-chan string
+	if o, ok := ea.locker.(Observer); ok {
+		in = o.Observe(ctx, ea.key)
+	} else {
+		in = ea._pollOwner(ctx, every)
+	}
+
+	out := make(chan string, 1)
+	go func() {
+		defer close(out)
+
+		last, sent := "", false
+		for owner := range in {
+			node := ParseNode(owner).ID
+			if sent && node == last {
+				continue
+			}
+			last, sent = node, true
+
+			select {
+			case out <- node:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// _pollOwner sends the owner of the lock each every, it is Observe for lockers without watch
+func (ea *EtcdAero) _pollOwner(ctx context.Context, every time.Duration) <-chan string {
+	out := make(chan string, 1)
+
+	go func() {
+		defer close(out)
+
+		for {
+			if owner, err := ea.locker.Owner(ctx, ea.key); err == nil {
+				select {
+				case out <- owner:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ea.clock.After(every):
+			}
+		}
+	}()
+
+	return out
+}
+
+// _watchLeader calls Config.OnLeaderChanged until ctx is done
+func (ea *EtcdAero) _watchLeader(ctx context.Context, every time.Duration, done chan struct{}) {
+	defer close(done)
+
+	for node := range ea._observe(ctx, every) {
+		ea.cfg.OnLeaderChanged(ea.key, node)
+	}
+}
@@ -0,0 +1,37 @@
+package etcdaero
+
+import (
+	"context"
+	"time"
+)
+
+// Locker is the coordination backend. The node which holds the lock
+// loads data from the source, others read it from the cache.
+// One Locker may serve many keys, owner is the node identity.
+type Locker interface {
+	// Acquire tries to take the free lock for ttl, it does not wait.
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Renew prolongs the lock which is held by owner.
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release gives up the lock if it is held by owner.
+	Release(ctx context.Context, key, owner string) error
+	// Owner returns the current lock holder or "" if the lock is free.
+	Owner(ctx context.Context, key string) (string, error)
+	// Close frees the backend connections.
+	Close() error
+}
+
+// Observer is Locker which watches the lock key.
+type Observer interface {
+	// Observe sends the owner of the lock when it changes, "" is the free lock.
+	// The current owner is sent first, the channel is closed when ctx is done.
+	Observe(ctx context.Context, key string) <-chan string
+}
+
+// NewLocker makes the etcd locker selected by cfg.EtcdAPI.
+func NewLocker(cfg *Config) (Locker, error) {
+	if cfg.EtcdAPI == EtcdAPIv3 {
+		return NewEtcdV3Locker(cfg.EtcdEndpoints)
+	}
+	return NewEtcdV2Locker(cfg.EtcdEndpoints)
+}
@@ -0,0 +1,240 @@
+package etcdaero
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/client"
+)
+
+// pause before the next watch after error
+const etcdV2WatchRetry = time.Second
+
+// EtcdV2Locker is the lock on etcd v2 KeysAPI: TTL'd Set with PrevExist/PrevIndex checks.
+type EtcdV2Locker struct {
+	sync.Mutex
+	client    client.Client
+	clientKey client.KeysAPI
+	prevIndex map[string]uint64
+	tokens    map[string]uint64
+}
+
+func NewEtcdV2Locker(endpoints []string) (*EtcdV2Locker, error) {
+	initCfg := client.Config{
+		Endpoints: endpoints,
+		Transport: client.DefaultTransport,
+		// set timeout per request to fail fast when the target endpoint is unavailable
+		HeaderTimeoutPerRequest: 2 * time.Second,
+	}
+
+	c, err := client.New(initCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtcdV2Locker{
+		client:    c,
+		clientKey: client.NewKeysAPI(c),
+		prevIndex: map[string]uint64{},
+		tokens:    map[string]uint64{},
+	}, nil
+}
+
+func (l *EtcdV2Locker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	resp, err := l.clientKey.Set(ctx, key, owner, _setOptions("", 0, ttl))
+	ok, err := l._updateResponse(key, resp, err)
+	if ok {
+		l.Lock()
+		l.tokens[key] = resp.Index
+		l.Unlock()
+	}
+	return ok, err
+}
+
+func (l *EtcdV2Locker) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.Lock()
+	prevIndex := l.prevIndex[key]
+	l.Unlock()
+
+	resp, err := l.clientKey.Set(ctx, key, owner, _setOptions(owner, prevIndex, ttl))
+	return l._updateResponse(key, resp, err)
+}
+
+func (l *EtcdV2Locker) Release(ctx context.Context, key, owner string) error {
+	l.Lock()
+	delete(l.prevIndex, key)
+	delete(l.tokens, key)
+	l.Unlock()
+
+	_, err := l.clientKey.Delete(ctx, key, _deleteOptions(owner))
+	return err
+}
+
+func (l *EtcdV2Locker) Owner(ctx context.Context, key string) (string, error) {
+	resp, err := l.clientKey.Get(ctx, key, nil)
+	if err != nil {
+		if e, ok := err.(client.Error); ok && e.Code == client.ErrorCodeKeyNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return resp.Node.Value, nil
+}
+
+func (l *EtcdV2Locker) Publish(ctx context.Context, key, version string) error {
+	_, err := l.clientKey.Set(ctx, key, version, nil)
+	return err
+}
+
+// Watch sends values of sets to key.
+func (l *EtcdV2Locker) Watch(ctx context.Context, key string) <-chan string {
+	out := make(chan string, 1)
+
+	go func() {
+		defer close(out)
+
+		l._watch(ctx, key, func(resp *client.Response) bool {
+			if resp.Action != "set" && resp.Action != "update" && resp.Action != "create" {
+				return true
+			}
+
+			select {
+			case out <- resp.Node.Value:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return out
+}
+
+// Observe sends the owner of the lock key when it changes, "" is the free lock.
+func (l *EtcdV2Locker) Observe(ctx context.Context, key string) <-chan string {
+	out := make(chan string, 1)
+
+	go func() {
+		defer close(out)
+
+		if owner, err := l.Owner(ctx, key); err == nil {
+			out <- owner
+		}
+
+		l._watch(ctx, key, func(resp *client.Response) bool {
+			owner := ""
+			switch resp.Action {
+			case "expire", "delete", "compareAndDelete":
+			default:
+				owner = resp.Node.Value
+			}
+
+			select {
+			case out <- owner:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return out
+}
+
+// _watch calls f for changes of key until ctx is done or f returns false.
+// The watch is restarted after errors.
+func (l *EtcdV2Locker) _watch(ctx context.Context, key string, f func(resp *client.Response) bool) {
+	w := l.clientKey.Watcher(key, nil)
+	for {
+		resp, err := w.Next(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			if e, ok := err.(client.Error); !ok || e.Code != client.ErrorCodeEventIndexCleared {
+				select {
+				case <-time.After(etcdV2WatchRetry):
+				case <-ctx.Done():
+					return
+				}
+			}
+			w = l.clientKey.Watcher(key, nil)
+			continue
+		}
+
+		if !f(resp) {
+			return
+		}
+	}
+}
+
+// Token is the modification index of the lock at acquire, etcd index only grows.
+func (l *EtcdV2Locker) Token(key string) uint64 {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.tokens[key]
+}
+
+func (l *EtcdV2Locker) Close() error {
+	return nil
+}
+
+// _updateResponse keeps index of the lock for the next renew.
+// The failed compare is not an error, the lock is just held by somebody else.
+func (l *EtcdV2Locker) _updateResponse(key string, resp *client.Response, err error) (bool, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	if err == nil {
+		l.prevIndex[key] = resp.Index
+		return true, nil
+	}
+
+	delete(l.prevIndex, key)
+
+	if e, ok := err.(client.Error); ok {
+		switch e.Code {
+		case client.ErrorCodeNodeExist, client.ErrorCodeTestFailed, client.ErrorCodeKeyNotFound:
+			return false, nil
+		}
+	}
+
+	return false, err
+}
+
+func _setOptions(prevVal string, prevIndex uint64, ttl time.Duration) *client.SetOptions {
+
+	out := &client.SetOptions{
+		TTL:       ttl,
+		Dir:       false,
+		PrevExist: client.PrevNoExist,
+	}
+
+	if prevVal != "" {
+		out.PrevValue = prevVal
+		out.PrevExist = client.PrevExist
+	}
+
+	if prevIndex > 0 {
+		out.PrevIndex = prevIndex
+		out.PrevExist = client.PrevExist
+	}
+
+	return out
+}
+
+func _deleteOptions(prevVal string) *client.DeleteOptions {
+	out := &client.DeleteOptions{
+		Recursive: true,
+		Dir:       false,
+	}
+	if prevVal != "" {
+		out.PrevValue = prevVal
+	}
+
+	return out
+}
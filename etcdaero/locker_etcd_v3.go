@@ -0,0 +1,336 @@
+package etcdaero
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	// how long one request to etcd v3 may take
+	etcdV3RequestTimeout = 2 * time.Second
+	// the pause before the closed watch is opened again
+	etcdV3WatchRetry = time.Second
+)
+
+// EtcdV3Locker is the lock on etcd v3 lease and concurrency.Election.
+// The session lease is kept alive by the etcd client itself,
+// leadership is lost when the lease expires.
+type EtcdV3Locker struct {
+	sync.Mutex
+	client    *clientv3.Client
+	elections map[string]*etcdV3Election
+}
+
+type etcdV3Election struct {
+	session  *concurrency.Session
+	election *concurrency.Election
+}
+
+func NewEtcdV3Locker(endpoints []string) (*EtcdV3Locker, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: etcdV3RequestTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtcdV3Locker{
+		client:    client,
+		elections: map[string]*etcdV3Election{},
+	}, nil
+}
+
+// Acquire campaigns without waiting in the queue.
+func (l *EtcdV3Locker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	e, err := l._election(key, ttl)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, etcdV3RequestTimeout)
+	defer cancel()
+
+	// somebody else is the leader, don't wait in the queue
+	_, err = e.election.Leader(ctx)
+	if err == nil {
+		return false, nil
+	}
+	if err != concurrency.ErrElectionNoLeader {
+		return false, err
+	}
+
+	if err := e.election.Campaign(ctx, owner); err != nil {
+		// Campaign resigns by itself when ctx is done
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// _election returns the election of key, it is made again if its session is expired.
+// The mutex is not held across RPCs, so keys of one locker don't wait for each other.
+func (l *EtcdV3Locker) _election(key string, ttl time.Duration) (*etcdV3Election, error) {
+	l.Lock()
+	e, ok := l.elections[key]
+	l.Unlock()
+
+	if ok && !e.expired() {
+		return e, nil
+	}
+
+	seconds := int(ttl.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	session, err := concurrency.NewSession(l.client, concurrency.WithTTL(seconds))
+	if err != nil {
+		return nil, err
+	}
+	fresh := &etcdV3Election{
+		session:  session,
+		election: concurrency.NewElection(session, key),
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	// other call has made it already
+	if e, ok := l.elections[key]; ok && !e.expired() {
+		session.Close()
+		return e, nil
+	}
+	l.elections[key] = fresh
+
+	return fresh, nil
+}
+
+// expired tells if the session lease is revoked or is not kept alive anymore
+func (e *etcdV3Election) expired() bool {
+	select {
+	case <-e.session.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Renew checks that the lease is alive and owner is still the leader.
+func (l *EtcdV3Locker) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.Lock()
+	e, ok := l.elections[key]
+	if ok && e.expired() {
+		delete(l.elections, key)
+		ok = false
+	}
+	l.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, etcdV3RequestTimeout)
+	defer cancel()
+
+	resp, err := e.election.Leader(ctx)
+	if err == concurrency.ErrElectionNoLeader {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return string(resp.Kvs[0].Key) == e.election.Key(), nil
+}
+
+// Release resigns and revokes the lease.
+func (l *EtcdV3Locker) Release(ctx context.Context, key, owner string) error {
+	l.Lock()
+	e, ok := l.elections[key]
+	delete(l.elections, key)
+	l.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, etcdV3RequestTimeout)
+	defer cancel()
+
+	err := e.election.Resign(ctx)
+	if errClose := e.session.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
+// Token is the create revision of the campaign key, etcd revision only grows.
+func (l *EtcdV3Locker) Token(key string) uint64 {
+	l.Lock()
+	defer l.Unlock()
+
+	e, ok := l.elections[key]
+	if !ok {
+		return 0
+	}
+	return uint64(e.election.Rev())
+}
+
+// Owner returns the value of the oldest campaign, it is the leader.
+func (l *EtcdV3Locker) Owner(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdV3RequestTimeout)
+	defer cancel()
+
+	resp, err := l.client.Get(ctx, key+"/", clientv3.WithFirstCreate()...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", nil
+	}
+
+	return string(resp.Kvs[0].Value), nil
+}
+
+func (l *EtcdV3Locker) Publish(ctx context.Context, key, version string) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdV3RequestTimeout)
+	defer cancel()
+
+	_, err := l.client.Put(ctx, key, version)
+	return err
+}
+
+// Watch sends values of puts to key until ctx is done.
+// The etcd client closes the watch when etcd has no leader or the revision is compacted,
+// then it is opened again and the current value is sent, puts between them are missed.
+func (l *EtcdV3Locker) Watch(ctx context.Context, key string) <-chan string {
+	out := make(chan string, 1)
+
+	go func() {
+		defer close(out)
+
+		send := func(value string) bool {
+			select {
+			case out <- value:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		l._watch(ctx, key, nil, func(resp clientv3.WatchResponse, reopened bool) bool {
+			if reopened {
+				if value, ok := l._get(ctx, key); ok {
+					return send(value)
+				}
+				return true
+			}
+
+			for _, ev := range resp.Events {
+				if ev.Type == clientv3.EventTypePut && !send(string(ev.Kv.Value)) {
+					return false
+				}
+			}
+			return true
+		})
+	}()
+
+	return out
+}
+
+// Observe sends the owner of the lock when campaigns change, "" is the free lock.
+// The watch is opened again if etcd client closes it, like Watch does.
+func (l *EtcdV3Locker) Observe(ctx context.Context, key string) <-chan string {
+	out := make(chan string, 1)
+
+	go func() {
+		defer close(out)
+
+		last, sent := "", false
+		send := func() bool {
+			owner, err := l.Owner(ctx, key)
+			if err != nil || sent && owner == last {
+				return true
+			}
+			last, sent = owner, true
+
+			select {
+			case out <- owner:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if !send() {
+			return
+		}
+		l._watch(ctx, key+"/", []clientv3.OpOption{clientv3.WithPrefix()}, func(resp clientv3.WatchResponse, reopened bool) bool {
+			return send()
+		})
+	}()
+
+	return out
+}
+
+// _watch calls f for each response of the watch of key until ctx is done or f returns false.
+// The closed watch is opened again after etcdV3WatchRetry, f is called with reopened then.
+func (l *EtcdV3Locker) _watch(ctx context.Context, key string, opts []clientv3.OpOption, f func(resp clientv3.WatchResponse, reopened bool) bool) {
+	for {
+		for resp := range l.client.Watch(clientv3.WithRequireLeader(ctx), key, opts...) {
+			if resp.Err() != nil {
+				// ErrNoLeader or compaction, the channel is closed after it
+				continue
+			}
+			if !f(resp, false) {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(etcdV3WatchRetry):
+		}
+
+		if !f(clientv3.WatchResponse{}, true) {
+			return
+		}
+	}
+}
+
+// _get returns the value of key
+func (l *EtcdV3Locker) _get(ctx context.Context, key string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, etcdV3RequestTimeout)
+	defer cancel()
+
+	resp, err := l.client.Get(ctx, key)
+	if err != nil || len(resp.Kvs) == 0 {
+		return "", false
+	}
+	return string(resp.Kvs[0].Value), true
+}
+
+func (l *EtcdV3Locker) Close() error {
+	l.Lock()
+	keys := make([]string, 0, len(l.elections))
+	for key := range l.elections {
+		keys = append(keys, key)
+	}
+	l.Unlock()
+
+	for _, key := range keys {
+		l.Release(context.Background(), key, "")
+	}
+
+	return l.client.Close()
+}
@@ -0,0 +1,214 @@
+package etcdaero
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LocalLocker is the in-process lock. All LocalLockers made from
+// one LocalLockTable share the locks, so it can stand for several
+// nodes in tests or for a single node without etcd.
+type LocalLocker struct {
+	table *LocalLockTable
+
+	mu     sync.Mutex
+	tokens map[string]uint64
+}
+
+// LocalLockTable keeps the in-process locks.
+type LocalLockTable struct {
+	sync.Mutex
+	Clock    Clock
+	locks    map[string]localLock
+	watchers map[string][]chan string
+	owners   map[string][]chan string
+	token    uint64
+}
+
+type localLock struct {
+	owner   string
+	expires time.Time
+}
+
+func NewLocalLockTable() *LocalLockTable {
+	return &LocalLockTable{
+		Clock:    realClock{},
+		locks:    map[string]localLock{},
+		watchers: map[string][]chan string{},
+		owners:   map[string][]chan string{},
+	}
+}
+
+// NewLocalLocker makes the locker on table, nil means a new table.
+func NewLocalLocker(table *LocalLockTable) *LocalLocker {
+	if table == nil {
+		table = NewLocalLockTable()
+	}
+	return &LocalLocker{table: table, tokens: map[string]uint64{}}
+}
+
+func (l *LocalLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	t := l.table
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t._get(key); ok {
+		return false, nil
+	}
+
+	t.locks[key] = localLock{owner: owner, expires: t.Clock.Now().Add(ttl)}
+	t.token++
+	_send(t.owners[key], owner)
+
+	l.mu.Lock()
+	l.tokens[key] = t.token
+	l.mu.Unlock()
+
+	return true, nil
+}
+
+// Token is the count of acquires of the table when the lock was taken.
+func (l *LocalLocker) Token(key string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.tokens[key]
+}
+
+func (l *LocalLocker) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	t := l.table
+	t.Lock()
+	defer t.Unlock()
+
+	lock, ok := t._get(key)
+	if !ok || lock.owner != owner {
+		return false, nil
+	}
+
+	t.locks[key] = localLock{owner: owner, expires: t.Clock.Now().Add(ttl)}
+	return true, nil
+}
+
+func (l *LocalLocker) Release(ctx context.Context, key, owner string) error {
+	t := l.table
+	t.Lock()
+	defer t.Unlock()
+
+	if lock, ok := t._get(key); ok && lock.owner == owner {
+		delete(t.locks, key)
+		_send(t.owners[key], "")
+	}
+
+	l.mu.Lock()
+	delete(l.tokens, key)
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *LocalLocker) Owner(ctx context.Context, key string) (string, error) {
+	t := l.table
+	t.Lock()
+	defer t.Unlock()
+
+	lock, _ := t._get(key)
+	return lock.owner, nil
+}
+
+func (l *LocalLocker) Close() error {
+	return nil
+}
+
+// Publish sends version to all watchers of key, only the last version is kept for slow ones.
+func (l *LocalLocker) Publish(ctx context.Context, key, version string) error {
+	t := l.table
+	t.Lock()
+	defer t.Unlock()
+
+	_send(t.watchers[key], version)
+
+	return nil
+}
+
+func (l *LocalLocker) Watch(ctx context.Context, key string) <-chan string {
+	return l.table._watch(ctx, l.table.watchers, key, nil)
+}
+
+// Observe sends the owner of the lock when it changes, "" is the free lock.
+// The expired lock is noticed on the next access to it.
+func (l *LocalLocker) Observe(ctx context.Context, key string) <-chan string {
+	t := l.table
+	return t._watch(ctx, t.owners, key, func(ch chan string) {
+		lock, _ := t._get(key)
+		ch <- lock.owner
+	})
+}
+
+// _watch adds channel to list of key until ctx is done, first fills it, caller does not hold the mutex.
+func (t *LocalLockTable) _watch(ctx context.Context, list map[string][]chan string, key string, first func(ch chan string)) <-chan string {
+	ch := make(chan string, 1)
+
+	t.Lock()
+	if first != nil {
+		first(ch)
+	}
+	list[key] = append(list[key], ch)
+	t.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		t.Lock()
+		defer t.Unlock()
+
+		chans := list[key]
+		for i := range chans {
+			if chans[i] == ch {
+				list[key] = append(chans[:i:i], chans[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch
+}
+
+// _send puts value to all chans, only the last value is kept for slow readers
+func _send(chans []chan string, value string) {
+	for _, ch := range chans {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- value
+	}
+}
+
+// Expire drops the lock as if its ttl is over.
+func (t *LocalLockTable) Expire(key string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.locks[key]; ok {
+		delete(t.locks, key)
+		_send(t.owners[key], "")
+	}
+}
+
+// _get returns the lock which is not expired yet, caller holds the mutex.
+func (t *LocalLockTable) _get(key string) (localLock, bool) {
+	lock, ok := t.locks[key]
+	if !ok {
+		return localLock{}, false
+	}
+
+	if !t.Clock.Now().Before(lock.expires) {
+		delete(t.locks, key)
+		_send(t.owners[key], "")
+		return localLock{}, false
+	}
+
+	return lock, true
+}
@@ -0,0 +1,157 @@
+package etcdaero
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"testing"
+	"time"
+)
+
+func TestLocalLocker(t *testing.T) {
+	TestingT(t)
+}
+
+type LocalLockerTestsSuite struct{}
+
+var _ = Suite(&LocalLockerTestsSuite{})
+
+func (s *LocalLockerTestsSuite) Test_Acquire_Renew_Release(c *C) {
+	//c.Skip("Not now")
+
+	ctx := context.Background()
+	table := NewLocalLockTable()
+	node1 := NewLocalLocker(table)
+	node2 := NewLocalLocker(table)
+
+	ok, err := node1.Acquire(ctx, "key", "node1", time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+
+	ok, err = node2.Acquire(ctx, "key", "node2", time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+
+	ok, _ = node2.Renew(ctx, "key", "node2", time.Minute)
+	c.Check(ok, Equals, false)
+
+	ok, _ = node1.Renew(ctx, "key", "node1", time.Minute)
+	c.Check(ok, Equals, true)
+
+	owner, err := node2.Owner(ctx, "key")
+	c.Assert(err, IsNil)
+	c.Check(owner, Equals, "node1")
+
+	// only the owner can release
+	c.Assert(node2.Release(ctx, "key", "node2"), IsNil)
+	owner, _ = node2.Owner(ctx, "key")
+	c.Check(owner, Equals, "node1")
+
+	c.Assert(node1.Release(ctx, "key", "node1"), IsNil)
+	owner, _ = node2.Owner(ctx, "key")
+	c.Check(owner, Equals, "")
+
+	ok, _ = node2.Acquire(ctx, "key", "node2", time.Minute)
+	c.Check(ok, Equals, true)
+}
+
+func (s *LocalLockerTestsSuite) Test_Expired(c *C) {
+	//c.Skip("Not now")
+
+	ctx := context.Background()
+	table := NewLocalLockTable()
+	node1 := NewLocalLocker(table)
+	node2 := NewLocalLocker(table)
+
+	ok, _ := node1.Acquire(ctx, "key", "node1", time.Millisecond)
+	c.Check(ok, Equals, true)
+
+	time.Sleep(5 * time.Millisecond)
+
+	ok, _ = node1.Renew(ctx, "key", "node1", time.Minute)
+	c.Check(ok, Equals, false)
+
+	ok, _ = node2.Acquire(ctx, "key", "node2", time.Minute)
+	c.Check(ok, Equals, true)
+}
+
+func (s *LocalLockerTestsSuite) Test_Publish_Watch(c *C) {
+	//c.Skip("Not now")
+
+	table := NewLocalLockTable()
+	node1 := NewLocalLocker(table)
+	node2 := NewLocalLocker(table)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := node2.Watch(ctx, "key")
+
+	c.Assert(node1.Publish(ctx, "other", "v0"), IsNil)
+	c.Assert(node1.Publish(ctx, "key", "v1"), IsNil)
+	c.Check(<-ch, Equals, "v1")
+
+	// slow watcher gets the last version only
+	c.Assert(node1.Publish(ctx, "key", "v2"), IsNil)
+	c.Assert(node1.Publish(ctx, "key", "v3"), IsNil)
+	c.Check(<-ch, Equals, "v3")
+
+	cancel()
+	_, ok := <-ch
+	c.Check(ok, Equals, false)
+	c.Assert(node1.Publish(context.Background(), "key", "v4"), IsNil)
+}
+
+func (s *LocalLockerTestsSuite) Test_Token(c *C) {
+	//c.Skip("Not now")
+
+	ctx := context.Background()
+	table := NewLocalLockTable()
+	node1 := NewLocalLocker(table)
+	node2 := NewLocalLocker(table)
+
+	c.Check(node1.Token("key"), Equals, uint64(0))
+
+	ok, _ := node1.Acquire(ctx, "key", "node1", time.Minute)
+	c.Assert(ok, Equals, true)
+	c.Check(node1.Token("key"), Equals, uint64(1))
+
+	// renew keeps the token, the next holder gets greater one
+	ok, _ = node1.Renew(ctx, "key", "node1", time.Minute)
+	c.Assert(ok, Equals, true)
+	c.Check(node1.Token("key"), Equals, uint64(1))
+
+	table.Expire("key")
+	ok, _ = node2.Acquire(ctx, "key", "node2", time.Minute)
+	c.Assert(ok, Equals, true)
+	c.Check(node2.Token("key"), Equals, uint64(2))
+	c.Check(node1.Token("key"), Equals, uint64(1))
+
+	c.Assert(node2.Release(ctx, "key", "node2"), IsNil)
+	c.Check(node2.Token("key"), Equals, uint64(0))
+}
+
+func (s *LocalLockerTestsSuite) Test_Observe(c *C) {
+	//c.Skip("Not now")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	table := NewLocalLockTable()
+	node1 := NewLocalLocker(table)
+	node2 := NewLocalLocker(table)
+
+	owners := node2.Observe(ctx, "key")
+	c.Check(<-owners, Equals, "")
+
+	node1.Acquire(ctx, "key", "node1", time.Minute)
+	c.Check(<-owners, Equals, "node1")
+
+	table.Expire("key")
+	c.Check(<-owners, Equals, "")
+
+	node2.Acquire(ctx, "key", "node2", time.Minute)
+	c.Check(<-owners, Equals, "node2")
+
+	node2.Release(ctx, "key", "node2")
+	c.Check(<-owners, Equals, "")
+
+	cancel()
+	_, ok := <-owners
+	c.Check(ok, Equals, false)
+}
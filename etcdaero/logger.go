@@ -0,0 +1,145 @@
+package etcdaero
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// the same message for the same key is written once per this interval by default
+const defLogInterval = time.Minute
+
+// logger writes structured logs, repeated messages are rate limited
+type logger struct {
+	sync.Mutex
+	log      *slog.Logger
+	clock    Clock
+	interval time.Duration
+	last     map[string]*logState
+	swept    time.Time
+}
+
+type logState struct {
+	at         time.Time
+	suppressed int
+}
+
+// newLogger makes logger on l, nil is slog.Default(), interval <= 0 is default one.
+func newLogger(l *slog.Logger, clock Clock, interval time.Duration) *logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	if clock == nil {
+		clock = realClock{}
+	}
+	if interval <= 0 {
+		interval = defLogInterval
+	}
+
+	return &logger{
+		log:      l,
+		clock:    clock,
+		interval: interval,
+		last:     map[string]*logState{},
+	}
+}
+
+// configLogger makes logger of cfg
+func configLogger(cfg *Config) *logger {
+	return newLogger(cfg.Logger, cfg.Clock, cfg.LogInterval)
+}
+
+// with makes logger with fields, rate limits are not shared
+func (l *logger) with(args ...any) *logger {
+	return newLogger(l.log.With(args...), l.clock, l.interval)
+}
+
+func (l *logger) debug(msg string, args ...any) {
+	l.log.Debug(msg, args...)
+}
+
+func (l *logger) info(msg string, args ...any) {
+	l.log.Info(msg, args...)
+}
+
+// limited writes msg once per interval for id, the count of skipped ones is added to the next
+func (l *logger) limited(level slog.Level, id, msg string, args ...any) {
+	if !l.log.Enabled(context.Background(), level) {
+		return
+	}
+
+	now := l.clock.Now()
+
+	l.Lock()
+	state, ok := l.last[id]
+	if ok && now.Sub(state.at) < l.interval {
+		state.suppressed++
+		l.Unlock()
+		return
+	}
+
+	suppressed := 0
+	if ok {
+		suppressed = state.suppressed
+	}
+	l._sweep(now)
+	l.last[id] = &logState{at: now}
+	l.Unlock()
+
+	if suppressed > 0 {
+		args = append(args, "suppressed", suppressed)
+	}
+	l.log.Log(context.Background(), level, msg, args...)
+}
+
+// _sweep drops states which are older than interval and have nothing suppressed,
+// so messages with unique text don't grow the map. It runs once per interval, caller holds the mutex.
+func (l *logger) _sweep(now time.Time) {
+	if now.Sub(l.swept) < l.interval {
+		return
+	}
+	l.swept = now
+
+	for id, state := range l.last {
+		if state.suppressed == 0 && now.Sub(state.at) >= l.interval {
+			delete(l.last, id)
+		}
+	}
+}
+
+// error writes err, *Error is written with its fields
+func (l *logger) error(err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		l.limited(slog.LevelError, err.Error(), "error", "error", err)
+		return
+	}
+
+	args := []any{"op", e.Op, "key", e.Key}
+	if e.Kind != nil {
+		args = append(args, "kind", e.Kind.Error())
+	}
+	if e.Err != nil {
+		args = append(args, "error", e.Err.Error())
+	}
+
+	l.limited(errorLevel(e.Kind), e.Op+"|"+e.Key+"|"+kindName(e.Kind), e.Op+" failed", args...)
+}
+
+// errorLevel is the log level of kind of Error
+func errorLevel(kind error) slog.Level {
+	switch kind {
+	case ErrEntryNotFound, ErrLockLost, ErrStaleToken, ErrAlreadyStarted, nil:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+func kindName(kind error) string {
+	if kind == nil {
+		return ""
+	}
+	return kind.Error()
+}
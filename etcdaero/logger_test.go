@@ -0,0 +1,126 @@
+package etcdaero
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	. "gopkg.in/check.v1"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	TestingT(t)
+}
+
+type LoggerTestsSuite struct{}
+
+var _ = Suite(&LoggerTestsSuite{})
+
+// stepClock is Clock which is moved by hand
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	return c.now
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time {
+	return make(chan time.Time)
+}
+
+func (s *LoggerTestsSuite) Test_Limited(c *C) {
+	//c.Skip("Not now")
+
+	buf := &bytes.Buffer{}
+	clock := &stepClock{now: time.Unix(0, 0)}
+	l := newLogger(slog.New(slog.NewTextHandler(buf, nil)), clock, time.Minute)
+
+	err := newError(OpRead, "set.pk", ErrEntryNotFound, nil)
+	for i := 0; i < 5; i++ {
+		l.error(err)
+	}
+	l.error(newError(OpRead, "set.other", ErrEntryNotFound, nil))
+	c.Check(strings.Count(buf.String(), "\n"), Equals, 2)
+
+	clock.now = clock.now.Add(time.Minute)
+	l.error(err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Check(lines[0], Matches, `.*level=WARN msg="read failed" op=read key=set.pk kind="entry is not found".*`)
+	c.Check(lines[2], Matches, `.*key=set.pk .*suppressed=4.*`)
+}
+
+func (s *LoggerTestsSuite) Test_Level(c *C) {
+	//c.Skip("Not now")
+
+	buf := &bytes.Buffer{}
+	l := newLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelError})), nil, 0)
+
+	l.error(newError(OpRead, "set.pk", ErrEntryNotFound, nil))
+	l.info("info")
+	c.Check(buf.String(), Equals, "")
+
+	l.error(newError(OpLoad, "key", ErrLoaderFailed, errors.New("db is down")))
+	c.Check(buf.String(), Matches, `.*level=ERROR msg="load failed" op=load key=key kind="loader failed" error="db is down"\n`)
+}
+
+func (s *LoggerTestsSuite) Test_Reader_Interval(c *C) {
+	//c.Skip("Not now")
+
+	buf := &bytes.Buffer{}
+	clock := &stepClock{now: time.Unix(0, 0)}
+	aero, err := newConfigAero(&Config{
+		Store:       &nopStore{},
+		Logger:      slog.New(slog.NewTextHandler(buf, nil)),
+		LogInterval: time.Hour,
+		Clock:       clock,
+	})
+	c.Assert(err, IsNil)
+	defer aero.Close()
+
+	// the reader-side warning is limited by Config.LogInterval and Config.Clock
+	aero.StartReader("lost")
+	for i := 0; i < 3; i++ {
+		aero._loadKey("lost")
+	}
+	c.Check(strings.Count(buf.String(), "entry is not found"), Equals, 1)
+
+	clock.now = clock.now.Add(time.Minute)
+	aero._loadKey("lost")
+	c.Check(strings.Count(buf.String(), "entry is not found"), Equals, 1)
+
+	clock.now = clock.now.Add(time.Hour)
+	aero._loadKey("lost")
+	c.Check(strings.Count(buf.String(), "entry is not found"), Equals, 2)
+	c.Check(buf.String(), Matches, `(?s).*key=lost.lost .*suppressed=3.*`)
+}
+
+func (s *LoggerTestsSuite) Test_Sweep(c *C) {
+	//c.Skip("Not now")
+
+	buf := &bytes.Buffer{}
+	clock := &stepClock{now: time.Unix(0, 0)}
+	l := newLogger(slog.New(slog.NewTextHandler(buf, nil)), clock, time.Minute)
+
+	// errors with unique text
+	for i := 0; i < 100; i++ {
+		l.error(fmt.Errorf("dial 10.0.0.%d: refused", i))
+	}
+	repeated := errors.New("repeated")
+	l.error(repeated)
+	l.error(repeated)
+	c.Check(l.last, HasLen, 101)
+
+	// old states are dropped, the one with suppressed messages is kept for its count
+	clock.now = clock.now.Add(time.Minute)
+	l.error(errors.New("other"))
+	c.Check(l.last, HasLen, 2)
+
+	l.error(repeated)
+	c.Check(buf.String(), Matches, `(?s).*msg=error error=repeated suppressed=1\n`)
+}
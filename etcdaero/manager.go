@@ -0,0 +1,254 @@
+package etcdaero
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDatasetExists  = errors.New("dataset is registered already")
+	ErrDatasetNoName  = errors.New("dataset has no name")
+	ErrDatasetNoLoad  = errors.New("dataset has no load function")
+	ErrManagerClosed  = errors.New("manager is closed")
+	ErrDatasetUnknown = errors.New("dataset is not registered")
+)
+
+// Dataset describes one cached dataset of Manager.
+// Only Name and Load are required.
+type Dataset struct {
+	// Name is used for Get, it is the default lock key and cache key
+	Name string
+	// LockKey is the etcd lock key
+	LockKey string
+	// CacheKey is the cache address, Set and Pk are Name by default
+	CacheKey *StoreKey
+	// Load gets data from source with Params
+	Load   LoadFunc
+	Params []interface{}
+	// Refresh is the refresh interval, 17 min 17 sec by default
+	Refresh time.Duration
+	// Reader prepares data, it is map[string]interface{} storage by default
+	Reader IAeroBody
+	// Codec encodes data, Config.Codec by default
+	Codec Codec
+}
+
+// Manager runs many datasets on one locker and one cache client.
+type Manager struct {
+	sync.RWMutex
+	cfg       *Config
+	locker    Locker
+	ownLocker bool
+	aero      *AeroChecker
+	ownAero   bool
+	list      map[string]*EtcdAero
+	ctx       context.Context
+	closed    bool
+}
+
+// NewManager makes Manager, locker and cache are from cfg like in New,
+// except the default AeroChecker: Manager has own one.
+func NewManager(cfg *Config) (*Manager, error) {
+	m := &Manager{
+		cfg:    cfg,
+		locker: cfg.Locker,
+		aero:   cfg.Aero,
+		list:   map[string]*EtcdAero{},
+	}
+
+	if m.aero == nil {
+		aero, err := newConfigAero(cfg)
+		if err != nil {
+			return nil, err
+		}
+		m.aero = aero
+		m.ownAero = true
+	}
+
+	if m.locker == nil {
+		locker, err := NewLocker(cfg)
+		if err != nil {
+			if m.ownAero {
+				m.aero.Close()
+			}
+			return nil, err
+		}
+		m.locker = locker
+		m.ownLocker = true
+	}
+
+	m.aero.Watch(notifierOf(cfg, m.locker))
+
+	return m, nil
+}
+
+// Register adds dataset, it is started at once if Manager is started.
+func (m *Manager) Register(ds Dataset) (*EtcdAero, error) {
+	if ds.Load == nil {
+		return nil, ErrDatasetNoLoad
+	}
+
+	return m._register(ds, loadFunc(m._codec(ds), ds.Load, ds.Params...), ds.Reader)
+}
+
+// _codec returns codec of dataset
+func (m *Manager) _codec(ds Dataset) Codec {
+	if ds.Codec != nil {
+		return ds.Codec
+	}
+	return m.cfg.Codec
+}
+
+// _register adds dataset with load and reader instead of ds.Load and ds.Reader.
+func (m *Manager) _register(ds Dataset, load entryLoader, reader IAeroBody) (*EtcdAero, error) {
+	if ds.Name == "" {
+		return nil, ErrDatasetNoName
+	}
+
+	lockKey := ds.LockKey
+	if lockKey == "" {
+		lockKey = ds.Name
+	}
+
+	cacheKey := ds.CacheKey
+	if cacheKey == nil {
+		cacheKey = storeKey(ds.Name)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	if _, ok := m.list[ds.Name]; ok {
+		return nil, ErrDatasetExists
+	}
+
+	ea, err := newEtcdAero(lockKey, cacheKey, m.cfg, m.aero, m.locker, load)
+	if err != nil {
+		return nil, err
+	}
+	// metrics of the leader are by dataset like the ones of the reader
+	ea.name = ds.Name
+	if ds.Refresh > 0 {
+		if err := ea.SetTTL(ds.Refresh); err != nil {
+			return nil, err
+		}
+	}
+
+	if reader == nil {
+		m.aero.StartReaderAt(ds.Name, cacheKey)
+	} else {
+		m.aero.StartReaderAt(ds.Name, cacheKey, reader)
+	}
+
+	if m.ctx != nil {
+		if err := ea.Start(m.ctx); err != nil {
+			m.aero.StopReader(ds.Name)
+			ea.Close()
+			return nil, err
+		}
+	}
+
+	m.list[ds.Name] = ea
+
+	return ea, nil
+}
+
+// Start runs all datasets until ctx is done or Stop is called.
+func (m *Manager) Start(ctx context.Context) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return ErrManagerClosed
+	}
+	if m.ctx != nil {
+		return ErrAlreadyStarted
+	}
+
+	m.ctx = ctx
+	for _, ea := range m.list {
+		if err := ea.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops all datasets, see EtcdAero.Stop.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.Lock()
+	m.ctx = nil
+	list := m._datasets()
+	m.Unlock()
+
+	var out error
+	for _, ea := range list {
+		if err := ea.Stop(ctx); err != nil && out == nil {
+			out = err
+		}
+	}
+
+	return out
+}
+
+// Close stops all datasets and closes the locker and the cache if they are not from Config.
+func (m *Manager) Close() error {
+	err := m.Stop(context.Background())
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return err
+	}
+	m.closed = true
+
+	m.aero.Unwatch(notifierOf(m.cfg, m.locker))
+
+	if m.ownLocker {
+		if errClose := m.locker.Close(); err == nil {
+			err = errClose
+		}
+	}
+	if m.ownAero {
+		m.aero.Close()
+	}
+
+	return err
+}
+
+// Get returns data of dataset name from its reader.
+func (m *Manager) Get(name string, params ...interface{}) (interface{}, bool) {
+	return m.aero.Get(name, params...)
+}
+
+// Dataset returns EtcdAero of dataset name.
+func (m *Manager) Dataset(name string) (*EtcdAero, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	ea, ok := m.list[name]
+	if !ok {
+		return nil, ErrDatasetUnknown
+	}
+	return ea, nil
+}
+
+// Aero returns the AeroChecker of Manager.
+func (m *Manager) Aero() *AeroChecker {
+	return m.aero
+}
+
+func (m *Manager) _datasets() []*EtcdAero {
+	out := make([]*EtcdAero, 0, len(m.list))
+	for _, ea := range m.list {
+		out = append(out, ea)
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package etcdaero
+
+import (
+	"time"
+)
+
+// Metrics gets measurements of datasets, etcdaeroprom has Prometheus one.
+// key is the dataset key. err is nil or *Error.
+type Metrics interface {
+	// Leader is called when the node gets or loses the lock of key
+	Leader(key string, leader bool)
+	// Load is the call of loader
+	Load(key string, d time.Duration, err error)
+	// Write is the write of size bytes to the cache, each retry too
+	Write(key string, size int, err error)
+	// Read is the read of size bytes from the cache by reader key
+	Read(key string, size int, err error)
+	// Reloaded is called when reader key has got the last data, it is loaded from source at loaded.
+	// It is called for not changed data too, loaded is zero if it is unknown.
+	Reloaded(key string, loaded time.Time)
+}
+
+// noMetrics is Metrics which does nothing
+type noMetrics struct{}
+
+func (noMetrics) Leader(key string, leader bool)              {}
+func (noMetrics) Load(key string, d time.Duration, err error) {}
+func (noMetrics) Write(key string, size int, err error)       {}
+func (noMetrics) Read(key string, size int, err error)        {}
+func (noMetrics) Reloaded(key string, loaded time.Time)       {}
+
+// metricsOf returns m or noMetrics if it is nil
+func metricsOf(m Metrics) Metrics {
+	if m == nil {
+		return noMetrics{}
+	}
+	return m
+}
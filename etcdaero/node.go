@@ -0,0 +1,74 @@
+package etcdaero
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Node is the identity of the lock holder, the lock value is Node as JSON.
+type Node struct {
+	ID   string            `json:"id"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+var (
+	defNodeOnce sync.Once
+	defNodeID   string
+	defNodeErr  error
+)
+
+// DefaultNodeID is unique for the process: hostname, pid and random suffix.
+func DefaultNodeID() (string, error) {
+	defNodeOnce.Do(func() {
+		hostname, err := os.Hostname()
+		if err != nil {
+			defNodeErr = err
+			return
+		}
+
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			defNodeErr = err
+			return
+		}
+
+		defNodeID = fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+	})
+	return defNodeID, defNodeErr
+}
+
+// configNode is the node of cfg, the default id is used if cfg.NodeID is empty
+func configNode(cfg *Config) (Node, error) {
+	node := Node{ID: cfg.NodeID, Meta: cfg.NodeMeta}
+	if node.ID == "" {
+		id, err := DefaultNodeID()
+		if err != nil {
+			return node, err
+		}
+		node.ID = id
+	}
+	return node, nil
+}
+
+// value is the lock value of node
+func (n Node) value() string {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return n.ID
+	}
+	return string(data)
+}
+
+// ParseNode reads the lock value, the value which is not JSON is the node id.
+func ParseNode(value string) Node {
+	node := Node{}
+	if !strings.HasPrefix(value, "{") || json.Unmarshal([]byte(value), &node) != nil {
+		return Node{ID: value}
+	}
+	return node
+}
@@ -0,0 +1,48 @@
+package etcdaero
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestNode(t *testing.T) {
+	TestingT(t)
+}
+
+type NodeTestsSuite struct{}
+
+var _ = Suite(&NodeTestsSuite{})
+
+func (s *NodeTestsSuite) Test_DefaultNodeID(c *C) {
+	//c.Skip("Not now")
+
+	id, err := DefaultNodeID()
+	c.Assert(err, IsNil)
+
+	hostname, _ := os.Hostname()
+	c.Check(strings.HasPrefix(id, hostname+"-"), Equals, true)
+	c.Check(strings.Count(id[len(hostname):], "-"), Equals, 2)
+
+	again, _ := DefaultNodeID()
+	c.Check(again, Equals, id)
+}
+
+func (s *NodeTestsSuite) Test_Value_ParseNode(c *C) {
+	//c.Skip("Not now")
+
+	node, err := configNode(&Config{NodeID: "worker-1", NodeMeta: map[string]string{"zone": "a", "version": "1.2"}})
+	c.Assert(err, IsNil)
+	c.Check(node.value(), Equals, `{"id":"worker-1","meta":{"version":"1.2","zone":"a"}}`)
+	c.Check(ParseNode(node.value()), DeepEquals, node)
+
+	node, err = configNode(&Config{NodeID: "worker-2"})
+	c.Assert(err, IsNil)
+	c.Check(node.value(), Equals, `{"id":"worker-2"}`)
+
+	// the value of old versions is the id
+	c.Check(ParseNode("host4001"), DeepEquals, Node{ID: "host4001"})
+	c.Check(ParseNode(""), DeepEquals, Node{})
+}
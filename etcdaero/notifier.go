@@ -0,0 +1,30 @@
+package etcdaero
+
+import (
+	"context"
+)
+
+// Notifier publishes the version of entry after the leader has written it,
+// so readers on other nodes reload it at once instead of waiting for the poll.
+// Lockers on etcd and LocalLocker are Notifiers.
+type Notifier interface {
+	// Publish sets the version of key
+	Publish(ctx context.Context, key, version string) error
+	// Watch sends the new versions of key, the channel is closed when ctx is done.
+	// It may be closed before if the watch is broken, AeroChecker opens it again then.
+	Watch(ctx context.Context, key string) <-chan string
+}
+
+// notifyKey is the notifier key of entry
+func notifyKey(key *StoreKey) string {
+	return "etcdaero/version/" + key.Set + "/" + key.Pk
+}
+
+// notifierOf returns cfg.Notifier or locker if it is Notifier
+func notifierOf(cfg *Config, locker Locker) Notifier {
+	if cfg.Notifier != nil {
+		return cfg.Notifier
+	}
+	n, _ := locker.(Notifier)
+	return n
+}
@@ -0,0 +1,89 @@
+package etcdaero
+
+import (
+	"context"
+	"hash/crc32"
+	"math/rand"
+	"time"
+)
+
+const (
+	defJitter = 0.2
+	// the pause grows up to defMaxBackoff pauses
+	defMaxBackoff = 4
+)
+
+// Schedule is the policy of tries to take the lock. The zero Schedule is
+// random jitter of 20%, backoff up to 4 pauses and no preference.
+type Schedule struct {
+	// Jitter spreads the pause between tries on ±Jitter part of it, negative is no jitter
+	Jitter float64
+	// NodeJitter makes the jitter fixed for the node id instead of random
+	NodeJitter bool
+	// MaxBackoff limits the pause which is doubled after each failed try, negative is no backoff.
+	// The try fails if the locker fails or the leadership ends with error.
+	MaxBackoff time.Duration
+	// Standby is the wait of the node before it takes the free lock,
+	// the preferred nodes with zero Standby try first. It should be longer than
+	// the pause of the preferred nodes, the leader is not replaced by them.
+	Standby time.Duration
+}
+
+// _pause is the wait before the next try to take the lock after fails failed tries
+func (ea *EtcdAero) _pause(t ttls, fails int) time.Duration {
+	sch := ea.cfg.Schedule
+	pause := t.sleep
+
+	maxPause := sch.MaxBackoff
+	if maxPause == 0 {
+		maxPause = pause * defMaxBackoff
+	}
+	for i := 0; i < fails && pause < maxPause; i++ {
+		pause *= 2
+	}
+	if maxPause > 0 && pause > maxPause {
+		pause = maxPause
+	}
+
+	jitter := sch.Jitter
+	if jitter == 0 {
+		jitter = defJitter
+	}
+	if jitter < 0 {
+		return pause
+	}
+
+	return time.Duration(float64(pause) * (1 + jitter*ea._spread()))
+}
+
+// _spread is in [-1, 1), random or fixed for the node id
+func (ea *EtcdAero) _spread() float64 {
+	if !ea.cfg.Schedule.NodeJitter {
+		return rand.Float64()*2 - 1
+	}
+
+	crc32q := crc32.MakeTable(0xD5828281)
+	return float64(int64(crc32.Checksum([]byte(ea.node.ID), crc32q))%1000-500) / 500
+}
+
+// _tryLock takes the lock, the node with Standby lets preferred nodes take the free lock first
+func (ea *EtcdAero) _tryLock(ctx context.Context, t ttls) (bool, error) {
+	if standby := ea.cfg.Schedule.Standby; standby > 0 {
+		owner, err := ea.locker.Owner(ctx, ea.key)
+		if err != nil {
+			ea._error(newError(OpLock, ea.key, ErrLockFailed, err))
+			return false, err
+		}
+		if owner != "" {
+			return false, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-ea.clock.After(standby):
+		}
+	}
+
+	return ea.getLock(ctx, t)
+}
@@ -0,0 +1,64 @@
+package etcdaero
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestSchedule(t *testing.T) {
+	TestingT(t)
+}
+
+type ScheduleTestsSuite struct{}
+
+var _ = Suite(&ScheduleTestsSuite{})
+
+func scheduleNode(c *C, id string, sch Schedule) *EtcdAero {
+	ea, err := newEtcdAero("schedule", storeKey("schedule"), &Config{NodeID: id, Schedule: sch}, nil, NewLocalLocker(nil), nil)
+	c.Assert(err, IsNil)
+	ea.SetTTL(time.Minute)
+	return ea
+}
+
+func (s *ScheduleTestsSuite) Test_Backoff(c *C) {
+	//c.Skip("Not now")
+
+	ea := scheduleNode(c, "node", Schedule{Jitter: -1})
+	c.Check(ea._pause(ea._ttls(), 0), Equals, 30*time.Second)
+	c.Check(ea._pause(ea._ttls(), 1), Equals, time.Minute)
+	c.Check(ea._pause(ea._ttls(), 2), Equals, 2*time.Minute)
+	c.Check(ea._pause(ea._ttls(), 10), Equals, 2*time.Minute)
+
+	ea = scheduleNode(c, "node", Schedule{Jitter: -1, MaxBackoff: 45 * time.Second})
+	c.Check(ea._pause(ea._ttls(), 1), Equals, 45*time.Second)
+
+	ea = scheduleNode(c, "node", Schedule{Jitter: -1, MaxBackoff: -1})
+	c.Check(ea._pause(ea._ttls(), 5), Equals, 30*time.Second)
+}
+
+func (s *ScheduleTestsSuite) Test_Random_Jitter(c *C) {
+	//c.Skip("Not now")
+
+	ea := scheduleNode(c, "node", Schedule{})
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		pause := ea._pause(ea._ttls(), 0)
+		c.Assert(pause >= 24*time.Second && pause <= 36*time.Second, Equals, true)
+		seen[pause] = true
+	}
+	c.Check(len(seen) > 1, Equals, true)
+}
+
+func (s *ScheduleTestsSuite) Test_Node_Jitter(c *C) {
+	//c.Skip("Not now")
+
+	// SetTTL is called before the node is known, the jitter is still from the node id
+	a := scheduleNode(c, "node-a", Schedule{NodeJitter: true, Jitter: 0.5})
+	b := scheduleNode(c, "node-b", Schedule{NodeJitter: true, Jitter: 0.5})
+
+	c.Check(a._pause(a._ttls(), 0), Equals, a._pause(a._ttls(), 0))
+	c.Check(a._pause(a._ttls(), 0), Not(Equals), b._pause(b._ttls(), 0))
+	c.Check(a._pause(a._ttls(), 0) >= 15*time.Second && a._pause(a._ttls(), 0) <= 45*time.Second, Equals, true)
+}
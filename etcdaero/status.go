@@ -0,0 +1,158 @@
+package etcdaero
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Status is the state of dataset on this node.
+type Status struct {
+	Key string `json:"key"`
+	// Node is the id of this node
+	Node string `json:"node,omitempty"`
+	// Leader is true if this node holds the lock, LockHolder is the node id of the lock owner
+	Leader         bool              `json:"leader"`
+	LockHolder     string            `json:"lock_holder,omitempty"`
+	LockHolderMeta map[string]string `json:"lock_holder_meta,omitempty"`
+	// Refreshed is the last successful refresh of the leader on this node
+	Refreshed time.Time `json:"refreshed,omitzero"`
+
+	// Version is the version of data in reader
+	Version string `json:"version,omitempty"`
+	// Loaded is the last ReNew of reader, Checked is the last successful check of cache
+	Loaded  time.Time `json:"loaded,omitzero"`
+	Checked time.Time `json:"checked,omitzero"`
+	// DataTime is when data is loaded from source, AgeSeconds is its age
+	DataTime   time.Time `json:"data_time,omitzero"`
+	AgeSeconds float64   `json:"age_seconds"`
+
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+}
+
+// readerState is the reader part of Status
+type readerState struct {
+	loaded    time.Time
+	checked   time.Time
+	dataTime  time.Time
+	lastErr   error
+	lastErrAt time.Time
+}
+
+// Age is the age of data, the time since Loaded for entries without DataTime
+func (st Status) Age(now time.Time) time.Duration {
+	switch {
+	case !st.DataTime.IsZero():
+		return now.Sub(st.DataTime)
+	case !st.Loaded.IsZero():
+		return now.Sub(st.Loaded)
+	}
+	return 0
+}
+
+// Status returns the reader states of all keys.
+func (aero *AeroChecker) Status(ctx context.Context) []Status {
+	keys := aero._keys()
+	sort.Strings(keys)
+
+	out := make([]Status, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, aero.keyStatus(key))
+	}
+	return out
+}
+
+// keyStatus returns the reader state of key
+func (aero *AeroChecker) keyStatus(key string) Status {
+	aero.RLock()
+	defer aero.RUnlock()
+
+	st := Status{
+		Key:     key,
+		Version: aero.versions[key],
+	}
+
+	if state, ok := aero.states[key]; ok {
+		st.Loaded = state.loaded
+		st.Checked = state.checked
+		st.DataTime = state.dataTime
+		if state.lastErr != nil {
+			st.LastError = state.lastErr.Error()
+			st.LastErrorAt = state.lastErrAt
+		}
+	}
+	st.AgeSeconds = st.Age(aero._now()).Seconds()
+
+	return st
+}
+
+// _state changes the reader state of key
+func (aero *AeroChecker) _state(key string, f func(state *readerState)) {
+	aero.Lock()
+	defer aero.Unlock()
+
+	if _, ok := aero.List[key]; !ok {
+		return
+	}
+
+	state, ok := aero.states[key]
+	if !ok {
+		state = &readerState{}
+		aero.states[key] = state
+	}
+	f(state)
+}
+
+// Status returns the state of dataset, the lock holder is asked from locker.
+func (ea *EtcdAero) Status(ctx context.Context) Status {
+	st := ea.Aero.keyStatus(ea.key)
+
+	st.Node = ea.node.ID
+
+	ea.stMu.Lock()
+	st.Leader = ea.leader
+	st.Refreshed = ea.refreshed
+	if ea.lastErr != nil && ea.lastErrAt.After(st.LastErrorAt) {
+		st.LastError = ea.lastErr.Error()
+		st.LastErrorAt = ea.lastErrAt
+	}
+	ea.stMu.Unlock()
+
+	if owner, err := ea.LeaderNode(ctx); err == nil {
+		st.LockHolder, st.LockHolderMeta = owner.ID, owner.Meta
+	}
+
+	return st
+}
+
+// Status returns the states of all datasets.
+func (m *Manager) Status(ctx context.Context) []Status {
+	m.RLock()
+	names := make([]string, 0, len(m.list))
+	for name := range m.list {
+		names = append(names, name)
+	}
+	m.RUnlock()
+	sort.Strings(names)
+
+	out := make([]Status, 0, len(names))
+	for _, name := range names {
+		ea, err := m.Dataset(name)
+		if err != nil {
+			continue
+		}
+
+		st := ea.Status(ctx)
+		// reader name may differ from the lock key
+		reader := m.aero.keyStatus(name)
+		reader.Key = name
+		reader.Node, reader.Leader, reader.Refreshed = st.Node, st.Leader, st.Refreshed
+		reader.LockHolder, reader.LockHolderMeta = st.LockHolder, st.LockHolderMeta
+		if st.LastErrorAt.After(reader.LastErrorAt) {
+			reader.LastError, reader.LastErrorAt = st.LastError, st.LastErrorAt
+		}
+		out = append(out, reader)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package etcdaero
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// StoreKey is the address of entry in the Store
+type StoreKey struct {
+	Set, Pk string
+	Tags    []string
+}
+
+// Store is the shared cache: the leader puts data, every node loads it.
+// AeroSpikeClient is the default Store.
+type Store interface {
+	// PutEntry stores data for ttl, it may be in background, errors are reported with putErrors
+	PutEntry(key *StoreKey, data IEntryData, ttl time.Duration)
+	// WriteEntry stores data for ttl at once and returns the error
+	WriteEntry(key *StoreKey, data IEntryData, ttl time.Duration) error
+	// LoadEntry fills buf, it returns false if entry is not found
+	// and the error if it is not read or broken
+	LoadEntry(key *StoreKey, buf IEntryData) (bool, error)
+	// DeleteEntry removes entry
+	DeleteEntry(key *StoreKey) error
+	// Close frees connections
+	Close()
+}
+
+// NewStore returns cfg.Store or makes aerospike client if it is not set.
+// The store is chunked if cfg.ChunkSize is set and versioned if cfg.Versioned is set.
+func NewStore(cfg *Config) (Store, error) {
+	store := cfg.Store
+	if store == nil {
+		client, err := NewAeroSpikeClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		store = client
+	}
+
+	errs := configPutErrors(cfg)
+
+	if cfg.ChunkSize > 0 {
+		cs := NewChunkedStore(store, cfg.ChunkSize)
+		cs.errs = errs
+		store = cs
+	}
+
+	if cfg.Versioned {
+		vs := NewVersionedStore(store)
+		vs.errs = errs
+		store = vs
+	}
+
+	return store, nil
+}
+
+// putErrors reports errors of PutEntry which does not return them,
+// stores of NewStore report to Config.OnError and Config.Logger
+type putErrors struct {
+	onError ErrorFunc
+	log     *logger
+}
+
+func configPutErrors(cfg *Config) putErrors {
+	return putErrors{
+		onError: cfg.OnError,
+		log:     configLogger(cfg),
+	}
+}
+
+func defPutErrors() putErrors {
+	return putErrors{log: newLogger(nil, nil, 0)}
+}
+
+func (p putErrors) report(key *StoreKey, err error) {
+	reportError(p.onError, p.log, newError(OpWrite, keyName(key), ErrStoreUnavailable, err))
+}
+
+// storeConfig is the part of Config which makes the store
+type storeConfig struct {
+	store     Store
+	hosts     string
+	namespace string
+	prefix    string
+	chunkSize int
+	versioned bool
+}
+
+func storeConfigOf(cfg *Config) storeConfig {
+	return storeConfig{
+		store:     cfg.Store,
+		hosts:     strings.Join(cfg.AeroHostsPots, ","),
+		namespace: cfg.AeroNamespace,
+		prefix:    cfg.AeroPrefix,
+		chunkSize: cfg.ChunkSize,
+		versioned: cfg.Versioned,
+	}
+}
+
+// same tells if both configs make the same store, Store is compared by pointer identity,
+// because stores of value types may be uncomparable and their copies are not the same store
+func (sc storeConfig) same(other storeConfig) bool {
+	return sameInstance(sc.store, other.store) &&
+		sc.hosts == other.hosts &&
+		sc.namespace == other.namespace &&
+		sc.prefix == other.prefix &&
+		sc.chunkSize == other.chunkSize &&
+		sc.versioned == other.versioned
+}
+
+// sameInstance tells if a and b are nil both or the same pointer,
+// values are never the same, they may be uncomparable
+func sameInstance(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != reflect.Pointer || vb.Kind() != reflect.Pointer {
+		return false
+	}
+	return va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
+
+// storeKey is the entry address of dataset key
+func storeKey(key string) *StoreKey {
+	return &StoreKey{
+		Set: key,
+		Pk:  key,
+	}
+}
@@ -0,0 +1,84 @@
+package etcdaero
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/iostrovok/aerospike_etcd_cache/etcdaero"
+
+// span attributes
+const (
+	attrKey         = attribute.Key("etcdaero.key")
+	attrSize        = attribute.Key("etcdaero.size")
+	attrCodec       = attribute.Key("etcdaero.codec")
+	attrCompression = attribute.Key("etcdaero.compression")
+	attrVersion     = attribute.Key("etcdaero.version")
+	attrAttempt     = attribute.Key("etcdaero.attempt")
+	attrSkipped     = attribute.Key("etcdaero.skipped")
+)
+
+// entryPropagator writes trace context of the leader into entry
+var entryPropagator = propagation.TraceContext{}
+
+// tracerOf returns tracer of tp, nil is no tracing
+func tracerOf(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startSpan starts child span with the tracer of the span in ctx
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err and ends span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// encodeEntry makes entry with codec in span
+func encodeEntry(ctx context.Context, data interface{}, codec Codec) (*EtcdAeroEntry, error) {
+	_, span := startSpan(ctx, "etcdaero.encode")
+	entry, err := NewEntryWithCodec(data, codec)
+	if err == nil {
+		span.SetAttributes(attrCodec.String(entry.Codec), attrSize.Int(len(entry.Body)))
+	}
+	endSpan(span, err)
+
+	return entry, err
+}
+
+// injectTrace keeps the trace context of ctx in entry
+func (entry *EtcdAeroEntry) injectTrace(ctx context.Context) {
+	carrier := propagation.MapCarrier{}
+	entryPropagator.Inject(ctx, carrier)
+	entry.Trace = carrier.Get("traceparent")
+}
+
+// traceLink is the link to the span which has written entry
+func (entry *EtcdAeroEntry) traceLink() (trace.Link, bool) {
+	if entry.Trace == "" {
+		return trace.Link{}, false
+	}
+
+	ctx := entryPropagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": entry.Trace})
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return trace.Link{}, false
+	}
+
+	return trace.Link{SpanContext: sc}, true
+}
@@ -0,0 +1,104 @@
+package etcdaero
+
+import (
+	"context"
+	"sync"
+)
+
+// Loader gets typed data from source.
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// TypedDataset is the dataset with compile-time checked type:
+// the leader stores T, every node reads T back without type assertions.
+type TypedDataset[T any] struct {
+	*EtcdAero
+	reader *typedReader[T]
+}
+
+// NewDataset makes typed dataset like New does, Start runs it.
+func NewDataset[T any](key string, cfg *Config, load Loader[T]) (*TypedDataset[T], error) {
+	ea, err := newEtcdAeroFrom(key, cfg, typedLoader(load, cfg.Codec))
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &typedReader[T]{}
+	ea.Aero.StartReaderAt(key, ea.cacheKey, reader)
+
+	return &TypedDataset[T]{
+		EtcdAero: ea,
+		reader:   reader,
+	}, nil
+}
+
+// RegisterDataset adds typed dataset to Manager, ds.Load, ds.Params and ds.Reader are not used.
+func RegisterDataset[T any](m *Manager, ds Dataset, load Loader[T]) (*TypedDataset[T], error) {
+	if load == nil {
+		return nil, ErrDatasetNoLoad
+	}
+
+	reader := &typedReader[T]{}
+	ea, err := m._register(ds, typedLoader(load, m._codec(ds)), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedDataset[T]{
+		EtcdAero: ea,
+		reader:   reader,
+	}, nil
+}
+
+// Get returns the last data from the cache, false if it is not loaded yet.
+func (ds *TypedDataset[T]) Get() (T, bool) {
+	return ds.reader.get()
+}
+
+// typedLoader adapts Loader to entryLoader
+func typedLoader[T any](load Loader[T], codec Codec) entryLoader {
+	return func(ctx context.Context) (*EtcdAeroEntry, error) {
+		data, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return encodeEntry(ctx, data, codec)
+	}
+}
+
+// typedReader is IAeroBody which decodes straight into T
+type typedReader[T any] struct {
+	sync.RWMutex
+	data  T
+	found bool
+}
+
+func (r *typedReader[T]) ReNew(data []byte) error {
+	return r.ReNewCodec(data, JSONCodec{})
+}
+
+func (r *typedReader[T]) ReNewCodec(data []byte, codec Codec) error {
+	var d T
+	if err := codec.Unmarshal(data, &d); err != nil {
+		return err
+	}
+
+	r.Lock()
+	r.data = d
+	r.found = true
+	r.Unlock()
+
+	return nil
+}
+
+func (r *typedReader[T]) Get(params []interface{}) (interface{}, bool, error) {
+	data, found := r.get()
+	return data, found, nil
+}
+
+func (r *typedReader[T]) get() (T, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.data, r.found
+}
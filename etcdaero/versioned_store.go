@@ -0,0 +1,144 @@
+package etcdaero
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var versionCounter uint64
+
+// binVersion is the bin of pointer record
+const binVersion = "version"
+
+// newVersion is unique for the process and grows with time
+func newVersion() string {
+	return fmt.Sprintf("%x-%x", time.Now().UnixNano(), atomic.AddUint64(&versionCounter, 1))
+}
+
+// Versioner is Store which keeps versions of entry.
+// AeroChecker does not reload the entry if its version is not changed.
+type Versioner interface {
+	// Version returns the current version of entry, "" for entry without versions
+	Version(key *StoreKey) (string, bool, error)
+	// LoadVersion loads version of entry
+	LoadVersion(key *StoreKey, version string, buf IEntryData) (bool, error)
+}
+
+// VersionedStore writes each entry under new generation key pk#<version>
+// and then flips the pointer record at the entry key to it.
+// Readers follow the pointer, so update is atomic. Old generations expire by ttl.
+type VersionedStore struct {
+	Store
+
+	errs putErrors
+}
+
+func NewVersionedStore(store Store) *VersionedStore {
+	return &VersionedStore{
+		Store: store,
+		errs:  defPutErrors(),
+	}
+}
+
+// versionKey is the key of version of entry
+func versionKey(key *StoreKey, version string) *StoreKey {
+	return &StoreKey{
+		Set:  key.Set,
+		Pk:   key.Pk + "#" + version,
+		Tags: key.Tags,
+	}
+}
+
+// PutEntry writes at once, errors are reported.
+func (vs *VersionedStore) PutEntry(key *StoreKey, data IEntryData, ttl time.Duration) {
+	if err := vs.WriteEntry(key, data, ttl); err != nil {
+		vs.errs.report(key, err)
+	}
+}
+
+// WriteEntry writes new generation and then the pointer.
+func (vs *VersionedStore) WriteEntry(key *StoreKey, data IEntryData, ttl time.Duration) error {
+	return vs.WriteFenced(key, data, ttl, 0)
+}
+
+// WriteFenced writes new generation and then the pointer with token.
+// The generation of the rejected write is not pointed and expires by ttl.
+// Data with the same hash is written again under the current version,
+// so the pointer is not flipped and readers don't reload it.
+func (vs *VersionedStore) WriteFenced(key *StoreKey, data IEntryData, ttl time.Duration, token uint64) error {
+	hash, _ := data.Export()[binHash].(string)
+
+	version := vs._sameVersion(key, hash)
+	if version == "" {
+		version = newVersion()
+	}
+
+	if err := vs.Store.WriteEntry(versionKey(key, version), data, ttl); err != nil {
+		return err
+	}
+
+	return writeFenced(vs.Store, key, binsEntry{binVersion: version, binHash: hash}, ttl, token)
+}
+
+// _sameVersion is the current version of entry if its hash is hash, else ""
+func (vs *VersionedStore) _sameVersion(key *StoreKey, hash string) string {
+	if hash == "" {
+		return ""
+	}
+
+	version, stored, ok, _ := vs._pointer(key)
+	if !ok || stored != hash {
+		return ""
+	}
+	return version
+}
+
+func (vs *VersionedStore) Version(key *StoreKey) (string, bool, error) {
+	version, _, ok, err := vs._pointer(key)
+	return version, ok, err
+}
+
+// _pointer loads the version and the hash of entry
+func (vs *VersionedStore) _pointer(key *StoreKey) (string, string, bool, error) {
+	pointer := binsEntry{binVersion: nil, binHash: nil}
+	if ok, err := vs.Store.LoadEntry(key, pointer); !ok {
+		return "", "", false, err
+	}
+
+	version, _ := pointer[binVersion].(string)
+	hash, _ := pointer[binHash].(string)
+	return version, hash, true, nil
+}
+
+// LoadVersion loads generation, "" is the entry which is written without VersionedStore.
+func (vs *VersionedStore) LoadVersion(key *StoreKey, version string, buf IEntryData) (bool, error) {
+	if version == "" {
+		return vs.Store.LoadEntry(key, buf)
+	}
+	return vs.Store.LoadEntry(versionKey(key, version), buf)
+}
+
+// LoadEntry follows the pointer.
+func (vs *VersionedStore) LoadEntry(key *StoreKey, buf IEntryData) (bool, error) {
+	version, ok, err := vs.Version(key)
+	if !ok {
+		return false, err
+	}
+	return vs.LoadVersion(key, version, buf)
+}
+
+// DeleteEntry removes the pointer and the current generation.
+func (vs *VersionedStore) DeleteEntry(key *StoreKey) error {
+	version, ok, err := vs.Version(key)
+	if err != nil {
+		return err
+	}
+	if ok && version != "" {
+		if err := vs.Store.DeleteEntry(versionKey(key, version)); err != nil {
+			return err
+		}
+	}
+
+	return vs.Store.DeleteEntry(key)
+}
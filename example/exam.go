@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"etcdaero"
 	"fmt"
 	"log"
@@ -46,6 +47,17 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// just for test, TTLs are set before Start, after it SetTTL returns ErrAlreadyStarted
+	if err := et.SetTTL(4 * time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	// Starts the refresh loop. Stop/Close releases the lock and waits for in-flight load.
+	if err := et.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	defer et.Close()
+
 	/*
 		Starts aerospike reader.
 		Default result is map[string]interface{}.
@@ -56,9 +68,6 @@ func main() {
 	fmt.Printf("Starts aerospike reader.\n")
 	etcdaero.StartAeroReader(keyETCD)
 
-	// just for test
-	et.SetTTL(4 * time.Second)
-
 	// here we're making something
 	time.Sleep(25 * time.Second)
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"etcdaero"
 	"fmt"
 	"log"
@@ -37,11 +38,16 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := et.SetTTL(4 * time.Second); err != nil {
+		log.Fatal(err)
+	}
+	if err := et.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	defer et.Close()
 
 	etcdaero.StartAeroReader(keyETCD, storage.NewlocalStorage())
 
-	et.SetTTL(4 * time.Second)
-
 	time.Sleep(25 * time.Second)
 
 	key := "ru"
@@ -11,28 +11,20 @@ import (
 
 type localStorage struct {
 	sync.RWMutex
-	etcdaero.IAeroBody
 	Data map[string]interface{}
 }
 
-// We want to single data for each goroutine.
-var Singleton *localStorage
+var _ etcdaero.IAeroBody = (*localStorage)(nil)
 
-func NewlocalStorage() localStorage {
-	if Singleton == nil {
-		Singleton = &localStorage{
-			Data: map[string]interface{}{},
-		}
+// One storage is shared by all goroutines, keep the pointer.
+func NewlocalStorage() *localStorage {
+	return &localStorage{
+		Data: map[string]interface{}{},
 	}
-	return *Singleton
-}
-
-func (puk localStorage) ReNew(data []byte) error {
-	return Singleton._ReNew(data)
 }
 
 // We can prepare out data for local storaging
-func (puk *localStorage) _ReNew(data []byte) error {
+func (puk *localStorage) ReNew(data []byte) error {
 	d := map[string]interface{}{}
 	if err := json.Unmarshal(data, &d); err != nil {
 		return err
@@ -57,11 +49,7 @@ func (puk *localStorage) _ReNew(data []byte) error {
 	return nil
 }
 
-func (puk localStorage) Get(data []interface{}) (interface{}, bool, error) {
-	return Singleton._Get(data)
-}
-
-func (puk *localStorage) _Get(data []interface{}) (interface{}, bool, error) {
+func (puk *localStorage) Get(data []interface{}) (interface{}, bool, error) {
 
 	if len(data) == 0 {
 		return nil, false, errors.New("no key")